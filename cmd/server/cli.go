@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/cli"
+	"github.com/aravi/code_execution_mcp/internal/adapters/logging"
+	mcpadapter "github.com/aravi/code_execution_mcp/internal/adapters/mcp"
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// newStack sets up logging and the executors described by cfg for a
+// subcommand
+func newStack(cfg *config.Config) cli.Stack {
+	setupLogging(cfg)
+	_, shellExecutor, pythonExecutor, goExecutor := newExecutors(cfg)
+	return cli.Stack{
+		Executors: []ports.CodeExecutor{shellExecutor, pythonExecutor, goExecutor},
+		Redactor:  mcpadapter.NewRedactor(cfg.Redaction),
+	}
+}
+
+// setupLogging makes the logger described by cfg, writing to stderr, the
+// default for a subcommand
+func setupLogging(cfg *config.Config) {
+	logger, _ := logging.New(cfg.Logging, os.Stderr)
+	slog.SetDefault(logger)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/cli"
+	"github.com/aravi/code_execution_mcp/internal/adapters/executor"
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// doctor reports the toolchains and sandbox features the server would find.
+// It returns 0 when there are no problems, 1 when there are, an invalid
+// sandbox configuration included, and 2 when the report cannot be written.
+func doctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: code-execution-mcp doctor [flags]")
+		fs.PrintDefaults()
+	}
+	loadConfig := configFlags(fs)
+	var opts cli.DoctorOptions
+	opts.Flags(fs)
+	fs.Parse(args)
+	cfg := loadConfig()
+
+	setupLogging(cfg)
+
+	// An invalid sandbox configuration is one of the problems to report;
+	// the host's features are probed all the same
+	var capabilities []domain.Capability
+	sandbox, sandboxErr := executor.NewSandbox(cfg.Sandbox)
+	if sandboxErr != nil {
+		capabilities = executor.ProbeCapabilities(cfg.Sandbox)
+	} else {
+		capabilities = sandbox.Capabilities()
+	}
+	toolchains := executor.ProbeToolchains(context.Background(), probeExecutors(cfg)...)
+
+	problems, err := cli.Doctor(toolchains.Toolchains(), capabilities, sandboxErr, opts, os.Stdout)
+	if err != nil {
+		slog.Error("Doctor failed", "error", err)
+		return 2
+	}
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// probeExecutors builds the Python and Go executors cfg describes, only
+// to probe their toolchains. They never run code, so they are built
+// without a sandbox, warm interpreters or a build cache.
+func probeExecutors(cfg *config.Config) []ports.CodeExecutor {
+	return []ports.CodeExecutor{
+		executor.NewPythonExecutor(config.PythonConfig{}, cfg.Policy.Python, nil, nil),
+		executor.NewGolangExecutor(config.GolangConfig{Modules: cfg.Golang.Modules}, cfg.Policy.Go, nil, nil),
+	}
+}
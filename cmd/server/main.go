@@ -2,14 +2,59 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/aravi/code_execution_mcp/internal/adapters/executor"
+	"github.com/aravi/code_execution_mcp/internal/adapters/history"
+	"github.com/aravi/code_execution_mcp/internal/adapters/logging"
 	mcpadapter "github.com/aravi/code_execution_mcp/internal/adapters/mcp"
+	"github.com/aravi/code_execution_mcp/internal/adapters/metrics"
+	"github.com/aravi/code_execution_mcp/internal/adapters/tracing"
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func main() {
+	// The first argument names a subcommand; without one, serve MCP
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run":
+			os.Exit(run(os.Args[2:]))
+		case "replay":
+			os.Exit(replay(os.Args[2:]))
+		case "doctor":
+			os.Exit(doctor(os.Args[2:]))
+		}
+	}
+	serve(os.Args[1:])
+}
+
+// serve runs the MCP server over stdio
+func serve(args []string) {
+	fs := flag.NewFlagSet("code-execution-mcp", flag.ExitOnError)
+	loadConfig := configFlags(fs)
+	fs.Parse(args)
+	cfg := loadConfig()
+
+	// Log to stderr, since stdout carries the stdio transport
+	logger, forwarder := logging.New(cfg.Logging, os.Stderr)
+	slog.SetDefault(logger)
+
+	// Record spans when a trace exporter is configured
+	tracer, err := tracing.NewProvider(cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	if tracer != nil {
+		tracing.SetProvider(tracer)
+	}
+
 	// Create MCP server with implementation info
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "code-execution-mcp",
@@ -17,22 +62,110 @@ func main() {
 	}, nil)
 
 	// Initialize executors (secondary/outbound adapters)
-	shellExecutor := executor.NewShellExecutor()
-	pythonExecutor := executor.NewPythonExecutor()
-	goExecutor := executor.NewGolangExecutor()
+	sandbox, shellExecutor, pythonExecutor, goExecutor := newExecutors(cfg)
+
+	// Find the toolchains, so that only the tools that can run are offered
+	toolchains := executor.ProbeToolchains(context.Background(), pythonExecutor, goExecutor)
+	for _, t := range toolchains.Toolchains() {
+		switch {
+		case t.Available:
+			slog.Info("Toolchain", "language", t.Language, "name", t.Name, "version", t.Version, "path", t.Path)
+		case !t.Optional:
+			slog.Warn("Toolchain unavailable; its tools are not registered", "language", t.Language, "name", t.Name, "detail", t.Detail)
+		}
+	}
+
+	// Record executions for Prometheus when an admin listener is configured
+	if cfg.Metrics.Listen != "" {
+		m := metrics.New()
+		shellExecutor = m.Instrument(shellExecutor)
+		pythonExecutor = m.Instrument(pythonExecutor)
+		goExecutor = m.Instrument(goExecutor)
+
+		listener, err := net.Listen("tcp", cfg.Metrics.Listen)
+		if err != nil {
+			fatal("Failed to start the metrics listener", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		go func() {
+			slog.Info("Serving metrics", "url", "http://"+listener.Addr().String()+"/metrics")
+			if err := http.Serve(listener, mux); err != nil {
+				slog.Error("Metrics listener stopped", "error", err)
+			}
+		}()
+	}
+	pipelineRunner := executor.NewPipelineRunner(observer{}, shellExecutor, pythonExecutor, goExecutor)
 
 	// Initialize MCP adapters (primary/inbound adapters) with dependencies
-	toolHandler := mcpadapter.NewToolHandler(shellExecutor, pythonExecutor, goExecutor)
-	promptHandler := mcpadapter.NewPromptHandler()
+	var executions ports.ExecutionHistory
+	if cfg.History.Enabled {
+		store, err := history.NewStore(cfg.History)
+		if err != nil {
+			fatal("Failed to open the execution history", err)
+		}
+		executions = store
+	}
+	toolHandler := mcpadapter.NewToolHandler(shellExecutor, pythonExecutor, goExecutor, pipelineRunner, sandbox, toolchains, executions, cfg)
+	promptHandler := mcpadapter.NewPromptHandler(toolchains)
 
-	// Register tools and prompts
+	// Register tools, resources and prompts, and forward logs to clients
+	// that ask
 	toolHandler.RegisterTools(server)
+	toolHandler.RegisterResources(server)
 	promptHandler.RegisterPrompts(server)
+	forwarder.Attach(server)
+
+	slog.Info("Starting Code Execution MCP Server")
+
+	// Run the server over stdin/stdout (stdio transport), then export the
+	// spans still queued
+	err = server.Run(context.Background(), &mcp.StdioTransport{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Error("Failed to export the remaining spans", "error", err)
+	}
+	if err != nil {
+		fatal("Server failed", err)
+	}
+}
 
-	log.Println("Starting Code Execution MCP Server...")
+// configFlags registers the flags selecting the configuration on fs and
+// returns a function loading it once fs is parsed
+func configFlags(fs *flag.FlagSet) func() *config.Config {
+	configPath := fs.String("config", os.Getenv("CODE_EXECUTION_MCP_CONFIG"), "path to a JSON configuration file")
+	profile := fs.String("profile", os.Getenv("CODE_EXECUTION_MCP_PROFILE"), "name of the policy profile to use from the configuration")
+	return func() *config.Config {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			fatal("Failed to load configuration", err)
+		}
+		if err := cfg.UseProfile(*profile); err != nil {
+			fatal("Failed to select policy profile", err)
+		}
+		return cfg
+	}
+}
 
-	// Run the server over stdin/stdout (stdio transport)
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		log.Fatalf("Server failed: %v", err)
+// newExecutors sets up the sandbox and the shell, Python and Go executors
+// described by cfg
+func newExecutors(cfg *config.Config) (*executor.Sandbox, ports.CodeExecutor, ports.CodeExecutor, ports.CodeExecutor) {
+	sandbox, err := executor.NewSandbox(cfg.Sandbox)
+	if err != nil {
+		fatal("Failed to set up the sandbox", err)
 	}
+	for _, c := range sandbox.Capabilities() {
+		slog.Info("Sandbox capability", "name", c.Name, "available", c.Available, "enabled", c.Enabled, "detail", c.Detail)
+	}
+	return sandbox,
+		executor.NewShellExecutor(cfg.Policy.Shell, sandbox, observer{}),
+		executor.NewPythonExecutor(cfg.Python, cfg.Policy.Python, sandbox, observer{}),
+		executor.NewGolangExecutor(cfg.Golang, cfg.Policy.Go, sandbox, observer{})
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/logging"
+	"github.com/aravi/code_execution_mcp/internal/adapters/tracing"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// observer reports the steps of executions to the tracing and logging
+// adapters
+type observer struct{}
+
+// StartSpan starts a span with the tracing provider set at startup
+func (observer) StartSpan(ctx context.Context, name string, attrs ...slog.Attr) ports.Span {
+	_, span := tracing.Start(ctx, name, spanAttributes(attrs)...)
+	return observedSpan{span}
+}
+
+// Logger returns the logger carried by ctx
+func (observer) Logger(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx)
+}
+
+// observedSpan adapts a tracing span, which may be nil, to ports.Span
+type observedSpan struct {
+	span *tracing.Span
+}
+
+func (s observedSpan) SetAttributes(attrs ...slog.Attr) {
+	s.span.SetAttributes(spanAttributes(attrs)...)
+}
+func (s observedSpan) SetError(message string) { s.span.SetError(message) }
+func (s observedSpan) End()                    { s.span.End() }
+
+// spanAttributes converts log attributes to span attributes
+func spanAttributes(attrs []slog.Attr) []tracing.Attribute {
+	converted := make([]tracing.Attribute, len(attrs))
+	for i, a := range attrs {
+		switch v := a.Value.Resolve(); v.Kind() {
+		case slog.KindInt64:
+			converted[i] = tracing.Attribute{Key: a.Key, Value: v.Int64()}
+		case slog.KindBool:
+			converted[i] = tracing.Bool(a.Key, v.Bool())
+		default:
+			converted[i] = tracing.String(a.Key, v.String())
+		}
+	}
+	return converted
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/cli"
+)
+
+// replay re-runs recorded executions and reports what changed. It returns
+// the exit status: 0 when nothing changed, 1 when something did or failed,
+// and 2 when the replay cannot run.
+func replay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: code-execution-mcp replay [flags] <history directory or file>")
+		fs.PrintDefaults()
+	}
+	loadConfig := configFlags(fs)
+	var opts cli.ReplayOptions
+	opts.Flags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	stack := newStack(loadConfig())
+
+	changed, err := cli.Replay(context.Background(), stack, opts, fs.Arg(0), os.Stdout)
+	if err != nil {
+		slog.Error("Replay failed", "error", err)
+		return 2
+	}
+	if changed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/cli"
+)
+
+// run executes a file, or code read from stdin, as the MCP tools would,
+// and prints their result. It returns the exit status of the code, or 2
+// when it cannot run.
+func run(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: code-execution-mcp run [flags] <file or -> [-- args...]")
+		fs.PrintDefaults()
+	}
+	loadConfig := configFlags(fs)
+	var opts cli.RunOptions
+	opts.Flags(fs)
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+	file, codeArgs := fs.Arg(0), fs.Args()[1:]
+	if len(codeArgs) > 0 && codeArgs[0] == "--" {
+		codeArgs = codeArgs[1:]
+	}
+	stack := newStack(loadConfig())
+
+	status, err := cli.Run(context.Background(), stack, opts, file, codeArgs, os.Stdin, os.Stdout)
+	if err != nil {
+		slog.Error("Run failed", "error", err)
+		return 2
+	}
+	return status
+}
@@ -2,7 +2,10 @@ module github.com/aravi/code_execution_mcp
 
 go 1.23.0
 
-require github.com/modelcontextprotocol/go-sdk v1.2.0
+require (
+	github.com/modelcontextprotocol/go-sdk v1.2.0
+	mvdan.cc/sh/v3 v3.12.0
+)
 
 require (
 	github.com/google/jsonschema-go v0.3.0 // indirect
@@ -0,0 +1,33 @@
+// Package cli implements the subcommands that drive the executors from a
+// terminal instead of through an MCP client.
+package cli
+
+import (
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+	"github.com/aravi/code_execution_mcp/internal/core/redact"
+)
+
+// Stack is what the subcommands run code with: the executors the server
+// would use, and the redactor it applies to their output
+type Stack struct {
+	Executors []ports.CodeExecutor
+	Redactor  *redact.Redactor
+}
+
+// executorFor returns the executor that supports language
+func (s Stack) executorFor(language string) (ports.CodeExecutor, bool) {
+	for _, executor := range s.Executors {
+		if executor.Supports(language) {
+			return executor, true
+		}
+	}
+	return nil, false
+}
+
+// redact masks secrets in the output of result, as the server does
+func (s Stack) redact(result *domain.ExecutionResult) {
+	if s.Redactor != nil {
+		s.Redactor.RedactResult(result)
+	}
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// DoctorOptions selects how the doctor reports
+type DoctorOptions struct {
+	JSON bool
+}
+
+// Flags registers the options on fs
+func (o *DoctorOptions) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.JSON, "json", false, "print the report as JSON")
+}
+
+// DoctorReport describes the environment executions would run in
+type DoctorReport struct {
+	Toolchains   []doctorToolchain  `json:"toolchains"`
+	Capabilities []doctorCapability `json:"capabilities"`
+	Problems     []string           `json:"problems"`
+}
+
+// doctorToolchain is a toolchain in the report
+type doctorToolchain struct {
+	Language  string            `json:"language"`
+	Name      string            `json:"name"`
+	Path      string            `json:"path,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Available bool              `json:"available"`
+	Detail    string            `json:"detail,omitempty"`
+	Optional  bool              `json:"optional,omitempty"`
+	Packages  map[string]string `json:"packages,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// doctorCapability is a sandbox feature in the report
+type doctorCapability struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Enabled   bool   `json:"enabled"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// Doctor writes a report on the toolchains and sandbox capabilities found
+// on the host to w. Missing toolchains that tools depend on, sandbox
+// features that are configured but unavailable, and sandboxErr, the error
+// the sandbox configuration was rejected with if any, are reported as
+// problems; it returns their number.
+func Doctor(toolchains []domain.Toolchain, capabilities []domain.Capability, sandboxErr error, opts DoctorOptions, w io.Writer) (int, error) {
+	report := DoctorReport{Toolchains: []doctorToolchain{}, Capabilities: []doctorCapability{}, Problems: []string{}}
+	if sandboxErr != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("the sandbox configuration is invalid, so the server will not start: %v", sandboxErr))
+	}
+	for _, t := range toolchains {
+		report.Toolchains = append(report.Toolchains, doctorToolchain(t))
+		if !t.Available && !t.Optional {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s toolchain %s is unavailable, so its tools are not registered: %s", t.Language, t.Name, t.Detail))
+		}
+	}
+	for _, c := range capabilities {
+		report.Capabilities = append(report.Capabilities, doctorCapability(c))
+		if c.Enabled && !c.Available {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is configured but unavailable, so executions will be rejected: %s", c.Name, c.Detail))
+		}
+	}
+
+	if opts.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return len(report.Problems), encoder.Encode(report)
+	}
+	writeDoctorReport(w, report)
+	return len(report.Problems), nil
+}
+
+// writeDoctorReport renders report for a terminal
+func writeDoctorReport(w io.Writer, report DoctorReport) {
+	fmt.Fprintln(w, "Toolchains")
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, t := range report.Toolchains {
+		status := "ok"
+		switch {
+		case !t.Available && t.Optional:
+			status = "absent"
+		case !t.Available:
+			status = "MISSING"
+		}
+		fmt.Fprintf(table, "  %s\t%s\t%s\t%s\t%s\n", status, t.Name, t.Version, t.Path, t.Detail)
+	}
+	table.Flush()
+	for _, t := range report.Toolchains {
+		if len(t.Packages) > 0 {
+			var packages []string
+			for _, name := range slices.Sorted(maps.Keys(t.Packages)) {
+				version := t.Packages[name]
+				if version == "" {
+					version = "-"
+				}
+				packages = append(packages, name+" "+version)
+			}
+			fmt.Fprintf(w, "  %s packages: %s\n", t.Name, strings.Join(packages, ", "))
+		}
+		if len(t.Env) > 0 {
+			var env []string
+			for _, name := range slices.Sorted(maps.Keys(t.Env)) {
+				env = append(env, name+"="+t.Env[name])
+			}
+			fmt.Fprintf(w, "  %s env: %s\n", t.Name, strings.Join(env, " "))
+		}
+	}
+
+	fmt.Fprintln(w, "\nSandbox")
+	table = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range report.Capabilities {
+		available, enabled := "available", "enabled"
+		if !c.Available {
+			available = "unavailable"
+		}
+		if !c.Enabled {
+			enabled = "disabled"
+		}
+		fmt.Fprintf(table, "  %s\t%s\t%s\t%s\n", c.Name, available, enabled, c.Detail)
+	}
+	table.Flush()
+
+	if len(report.Problems) == 0 {
+		fmt.Fprintln(w, "\nNo problems found")
+		return
+	}
+	fmt.Fprintln(w, "\nProblems")
+	for _, problem := range report.Problems {
+		fmt.Fprintf(w, "  - %s\n", problem)
+	}
+}
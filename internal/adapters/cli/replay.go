@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/history"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/textdiff"
+)
+
+// scratchName matches the random part of the temporary files and
+// directories executions run from, which differs on every run
+var scratchName = regexp.MustCompile(`mcp_(python|golang|home)_[0-9]+`)
+
+// Replay outcomes
+const (
+	replayUnchanged = "unchanged"
+	replayChanged   = "changed"
+	replayFailed    = "failed"
+)
+
+// ReplayOptions selects the recorded executions to replay and how to
+// report on them
+type ReplayOptions struct {
+	Languages string
+	Since     string
+	Until     string
+	JSON      bool
+}
+
+// Flags registers the options on fs
+func (o *ReplayOptions) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Languages, "lang", "", "comma-separated languages to replay (default all)")
+	fs.StringVar(&o.Since, "since", "", "only replay executions from this date (YYYY-MM-DD or RFC 3339) on")
+	fs.StringVar(&o.Until, "until", "", "only replay executions up to this date (YYYY-MM-DD, inclusive, or RFC 3339)")
+	fs.BoolVar(&o.JSON, "json", false, "print the report as JSON")
+}
+
+// ReplayReport is the outcome of a replay
+type ReplayReport struct {
+	Total      int             `json:"total"`
+	Unchanged  int             `json:"unchanged"`
+	Changed    int             `json:"changed"`
+	Failed     int             `json:"failed"`
+	Executions []ReplayOutcome `json:"executions"`
+}
+
+// ReplayOutcome compares one recorded execution with its replay
+type ReplayOutcome struct {
+	ID         string       `json:"id"`
+	Time       time.Time    `json:"time"`
+	Language   string       `json:"language"`
+	Tool       string       `json:"tool,omitempty"`
+	Status     string       `json:"status"`
+	Changes    []string     `json:"changes,omitempty"`
+	Before     replayStatus `json:"before"`
+	After      replayStatus `json:"after"`
+	StdoutDiff string       `json:"stdout_diff,omitempty"`
+	StderrDiff string       `json:"stderr_diff,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// replayStatus is how an execution ended
+type replayStatus struct {
+	ExitCode  int    `json:"exit_code"`
+	ErrorType string `json:"error_type"`
+}
+
+// Replay re-runs the executions recorded at path, a history directory or
+// file, through stack and writes a report of those whose exit code, error
+// type or output changed to w. Output is compared after redaction and
+// with the names of temporary files masked. It returns the number of
+// executions that changed or could not be replayed.
+func Replay(ctx context.Context, stack Stack, opts ReplayOptions, path string, w io.Writer) (int, error) {
+	entries, err := history.Load(path)
+	if err != nil {
+		return 0, err
+	}
+	keep, err := opts.filter()
+	if err != nil {
+		return 0, err
+	}
+
+	report := ReplayReport{Executions: []ReplayOutcome{}}
+	for _, entry := range entries {
+		if !keep(entry) {
+			continue
+		}
+		outcome := replayEntry(ctx, stack, entry)
+		report.Total++
+		switch outcome.Status {
+		case replayUnchanged:
+			report.Unchanged++
+		case replayChanged:
+			report.Changed++
+		default:
+			report.Failed++
+		}
+		report.Executions = append(report.Executions, outcome)
+	}
+
+	if opts.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return 0, err
+		}
+	} else {
+		writeReplayReport(w, report)
+	}
+	return report.Changed + report.Failed, nil
+}
+
+// filter returns the predicate selecting the entries opts asks for
+func (o ReplayOptions) filter() (func(domain.HistoryEntry) bool, error) {
+	languages := map[string]bool{}
+	for _, language := range strings.Split(o.Languages, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			languages[language] = true
+		}
+	}
+	since, err := parseDate(o.Since, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -since: %w", err)
+	}
+	until, err := parseDate(o.Until, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -until: %w", err)
+	}
+
+	return func(entry domain.HistoryEntry) bool {
+		if len(languages) > 0 && !languages[entry.Request.Language] {
+			return false
+		}
+		if !since.IsZero() && entry.Time.Before(since) {
+			return false
+		}
+		if !until.IsZero() && !entry.Time.Before(until) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// parseDate parses an RFC 3339 time or a date. A date that ends a range
+// stands for the end of that day.
+func parseDate(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC 3339", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// replayEntry runs entry again and compares the results
+func replayEntry(ctx context.Context, stack Stack, entry domain.HistoryEntry) ReplayOutcome {
+	outcome := ReplayOutcome{
+		ID:       entry.ID,
+		Time:     entry.Time,
+		Language: entry.Request.Language,
+		Tool:     entry.Request.Tool,
+		Before:   replayStatus{entry.Result.ExitCode, entry.Result.ErrorType.String()},
+	}
+
+	executor, ok := stack.executorFor(entry.Request.Language)
+	if !ok {
+		outcome.Status, outcome.Error = replayFailed, fmt.Sprintf("unsupported language %q", entry.Request.Language)
+		return outcome
+	}
+	req := entry.Request
+	req.Session = ""
+	result, err := executor.Execute(ctx, req)
+	if err != nil {
+		outcome.Status, outcome.Error = replayFailed, err.Error()
+		return outcome
+	}
+	stack.redact(result)
+	outcome.After = replayStatus{result.ExitCode, result.ErrorType.String()}
+
+	if outcome.Before.ExitCode != outcome.After.ExitCode {
+		outcome.Changes = append(outcome.Changes, "exit_code")
+	}
+	if outcome.Before.ErrorType != outcome.After.ErrorType {
+		outcome.Changes = append(outcome.Changes, "error_type")
+	}
+	outcome.StdoutDiff = outputDiff(entry.Result.Stdout, result.Stdout, "stdout")
+	if outcome.StdoutDiff != "" {
+		outcome.Changes = append(outcome.Changes, "stdout")
+	}
+	outcome.StderrDiff = outputDiff(entry.Result.Stderr, result.Stderr, "stderr")
+	if outcome.StderrDiff != "" {
+		outcome.Changes = append(outcome.Changes, "stderr")
+	}
+
+	outcome.Status = replayUnchanged
+	if len(outcome.Changes) > 0 {
+		outcome.Status = replayChanged
+	}
+	return outcome
+}
+
+// outputDiff diffs recorded and replayed output with temporary file names
+// masked
+func outputDiff(recorded, replayed, stream string) string {
+	recorded = scratchName.ReplaceAllString(recorded, "mcp_${1}_*")
+	replayed = scratchName.ReplaceAllString(replayed, "mcp_${1}_*")
+	return textdiff.Unified(recorded, replayed, "recorded "+stream, "replayed "+stream)
+}
+
+// writeReplayReport renders report for a terminal
+func writeReplayReport(w io.Writer, report ReplayReport) {
+	for _, outcome := range report.Executions {
+		if outcome.Status == replayUnchanged {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s (%s, %s)\n", strings.ToUpper(outcome.Status), outcome.ID, outcome.Language, outcome.Time.Local().Format(time.DateTime))
+		if outcome.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n\n", outcome.Error)
+			continue
+		}
+		if outcome.Before.ExitCode != outcome.After.ExitCode {
+			fmt.Fprintf(w, "  exit code: %d -> %d\n", outcome.Before.ExitCode, outcome.After.ExitCode)
+		}
+		if outcome.Before.ErrorType != outcome.After.ErrorType {
+			fmt.Fprintf(w, "  error type: %s -> %s\n", outcome.Before.ErrorType, outcome.After.ErrorType)
+		}
+		for _, diff := range []string{outcome.StdoutDiff, outcome.StderrDiff} {
+			if diff != "" {
+				fmt.Fprintf(w, "%s\n", strings.TrimRight(diff, "\n"))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Replayed %d executions: %d unchanged, %d changed, %d failed\n", report.Total, report.Unchanged, report.Changed, report.Failed)
+}
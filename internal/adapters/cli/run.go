@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	mcpadapter "github.com/aravi/code_execution_mcp/internal/adapters/mcp"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// languageByExtension infers the language of a script from its file name
+var languageByExtension = map[string]string{
+	".sh":   "bash",
+	".bash": "bash",
+	".py":   "python",
+	".go":   "go",
+}
+
+// RunOptions describes a single execution from the terminal. They mirror
+// the inputs of the execution tools.
+type RunOptions struct {
+	Language  string
+	JSON      bool
+	Stdin     string
+	Dir       string
+	Timeout   int
+	Network   string
+	Mode      string
+	TestFile  string
+	Profile   bool
+	Coverage  bool
+	Artifacts string
+}
+
+// Flags registers the options on fs
+func (o *RunOptions) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Language, "lang", "", "language of the code: bash, python or go (default from the file extension)")
+	fs.BoolVar(&o.JSON, "json", false, "print the tool result as JSON instead of markdown")
+	fs.StringVar(&o.Stdin, "stdin", "", "file to pass to the program as standard input")
+	fs.StringVar(&o.Dir, "dir", "", "working directory to run in")
+	fs.IntVar(&o.Timeout, "timeout", 0, "timeout in seconds (default the executor's)")
+	fs.StringVar(&o.Network, "network", "", "network mode: none, allowlist or host (default the configured mode)")
+	fs.StringVar(&o.Mode, "mode", "", "Go mode: run or test")
+	fs.StringVar(&o.TestFile, "test", "", "file holding the Go tests to run in test mode")
+	fs.BoolVar(&o.Profile, "profile-code", false, "run the code under its profiler")
+	fs.BoolVar(&o.Coverage, "coverage", false, "record line coverage")
+	fs.StringVar(&o.Artifacts, "artifacts", "", "directory to save artifacts such as profiles in")
+}
+
+// Run executes the code in file, or read from stdin when file is "-",
+// through stack, passing it args, and writes the result to w as the MCP
+// tools would return it. It returns the exit status of the code: 0 when it
+// ran successfully, otherwise its exit code, or 1 when it has none.
+func Run(ctx context.Context, stack Stack, opts RunOptions, file string, args []string, stdin io.Reader, w io.Writer) (int, error) {
+	language := opts.Language
+	if language == "" {
+		language = languageByExtension[filepath.Ext(file)]
+	}
+	if language == "" {
+		return 0, errors.New("cannot tell the language of the code; set -lang")
+	}
+	executor, ok := stack.executorFor(language)
+	if !ok {
+		return 0, fmt.Errorf("unsupported language %q", language)
+	}
+
+	var code []byte
+	var err error
+	if file == "-" {
+		code, err = io.ReadAll(stdin)
+	} else {
+		code, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading code: %w", err)
+	}
+
+	req := domain.ExecutionRequest{
+		Language:   language,
+		Args:       args,
+		WorkingDir: opts.Dir,
+		Timeout:    opts.Timeout,
+		Network:    opts.Network,
+		Mode:       opts.Mode,
+		Profile:    opts.Profile,
+		Coverage:   opts.Coverage,
+	}
+	if mcpadapter.LanguageLabel(language) == "Bash" {
+		req.Script = string(code)
+	} else {
+		req.Code = string(code)
+	}
+	if opts.Stdin != "" {
+		input, err := os.ReadFile(opts.Stdin)
+		if err != nil {
+			return 0, fmt.Errorf("reading stdin: %w", err)
+		}
+		req.Stdin = string(input)
+	}
+	if opts.TestFile != "" {
+		test, err := os.ReadFile(opts.TestFile)
+		if err != nil {
+			return 0, fmt.Errorf("reading tests: %w", err)
+		}
+		req.TestCode = string(test)
+	}
+
+	result, err := executor.Execute(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	stack.redact(result)
+	response := mcpadapter.FormatResult(result, mcpadapter.LanguageLabel(language))
+
+	if opts.Artifacts != "" {
+		if err := saveArtifacts(opts.Artifacts, result.Artifacts); err != nil {
+			return 0, err
+		}
+	}
+	if opts.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(response); err != nil {
+			return 0, err
+		}
+	} else {
+		writeResponse(w, response)
+	}
+
+	switch {
+	case !result.IsError:
+		return 0, nil
+	case result.ExitCode > 0:
+		return result.ExitCode, nil
+	default:
+		return 1, nil
+	}
+}
+
+// writeResponse prints the markdown of response, and lists the resources
+// it embeds
+func writeResponse(w io.Writer, response *sdk.CallToolResult) {
+	for _, content := range response.Content {
+		switch c := content.(type) {
+		case *sdk.TextContent:
+			fmt.Fprintf(w, "%s\n", strings.TrimRight(c.Text, "\n"))
+		case *sdk.EmbeddedResource:
+			fmt.Fprintf(w, "\n**Artifact:** `%s` (%s, %d bytes)\n", c.Resource.URI, c.Resource.MIMEType, len(c.Resource.Blob))
+		}
+	}
+}
+
+// saveArtifacts writes artifacts to dir
+func saveArtifacts(dir string, artifacts []domain.Artifact) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating artifact directory: %w", err)
+	}
+	for _, artifact := range artifacts {
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(artifact.Name)), artifact.Data, 0644); err != nil {
+			return fmt.Errorf("saving artifact: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BinaryCache stores compiled binaries in a directory, keyed by a hash of
+// everything that went into the build. The total size of the directory is
+// kept under maxBytes by evicting the least recently used entries.
+type BinaryCache struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+
+	// copyOut checks binaries out as copies instead of hard links. It is
+	// set when runs are handed to another user, since handing over a link
+	// would hand over the cached binary itself.
+	copyOut bool
+}
+
+// NewBinaryCache creates a cache rooted at dir
+func NewBinaryCache(dir string, maxBytes int64) (*BinaryCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating build cache directory: %w", err)
+	}
+	return &BinaryCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Key hashes the build inputs into a cache key. Files are hashed in name
+// order so the key does not depend on map iteration.
+func (c *BinaryCache) Key(files map[string][]byte, toolchain []byte, buildArgs []string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "file %s %d\n", name, len(files[name]))
+		h.Write(files[name])
+	}
+	fmt.Fprintf(h, "toolchain %d\n", len(toolchain))
+	h.Write(toolchain)
+	fmt.Fprintf(h, "args %q\n", buildArgs)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Lookup checks the cached binary for key out into dir, if present, and
+// returns the path of the copy. A hit refreshes the entry's modification
+// time for LRU eviction. The binary is checked out under the cache lock,
+// so a concurrent eviction cannot remove it before the caller runs it.
+func (c *BinaryCache) Lookup(key, dir string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	checkedOut, err := c.checkout(path, dir)
+	if err != nil {
+		return "", false
+	}
+	return checkedOut, true
+}
+
+// TempPath returns a fresh path inside the cache directory to build into,
+// so that Store can move the result into place with a rename
+func (c *BinaryCache) TempPath(key string) string {
+	return filepath.Join(c.dir, fmt.Sprintf(".tmp-%s-%d%s", key[:16], time.Now().UnixNano(), exeSuffix()))
+}
+
+// Store moves a freshly built binary into the cache, checks it out into
+// dir as Lookup does, and evicts old entries
+func (c *BinaryCache) Store(key, built, dir string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	if err := os.Rename(built, path); err != nil {
+		os.Remove(built)
+		// A concurrent build of the same source may have won the race
+		if _, statErr := os.Stat(path); statErr != nil {
+			return "", fmt.Errorf("storing binary in cache: %w", err)
+		}
+	}
+	checkedOut, err := c.checkout(path, dir)
+	if err != nil {
+		return "", fmt.Errorf("checking out cached binary: %w", err)
+	}
+
+	c.evict(path)
+	return checkedOut, nil
+}
+
+// checkout links the cached binary at path into dir, or copies it there
+// when copyOut is set or dir is on another file system
+func (c *BinaryCache) checkout(path, dir string) (string, error) {
+	target := filepath.Join(dir, "main"+exeSuffix())
+	if !c.copyOut {
+		if err := os.Link(path, target); err == nil {
+			return target, nil
+		}
+	}
+	return target, copyFile(path, target)
+}
+
+// copyFile copies the executable at src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// evict removes least recently used binaries until the cache fits in
+// maxBytes. The entry at keep is never removed. The caller holds c.mu.
+func (c *BinaryCache) evict(keep string) {
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{
+			path:    filepath.Join(c.dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if f.path == keep {
+			continue
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+}
+
+// path returns the location of the binary for key
+func (c *BinaryCache) path(key string) string {
+	return filepath.Join(c.dir, key+exeSuffix())
+}
+
+// exeSuffix returns the executable file extension for the host OS
+func exeSuffix() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aravi/code_execution_mcp/internal/core/coverage"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// collectGoCoverage converts the coverage data written to coverDir into a
+// text profile and summarizes it per file. files are the sources that were
+// built, keyed by file name.
+func (e *GolangExecutor) collectGoCoverage(coverDir string, files map[string][]byte, result *domain.ExecutionResult) {
+	report := &domain.CoverageReport{Tool: "go cover"}
+	result.Coverage = report
+
+	entries, err := os.ReadDir(coverDir)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	profile := filepath.Join(filepath.Dir(coverDir), "cover.out")
+	if _, err := e.goTool("tool", "covdata", "textfmt", "-i="+coverDir, "-o="+profile); err != nil {
+		result.Stderr += fmt.Sprintf("\nError reading coverage data: %v", err)
+		return
+	}
+	data, err := os.ReadFile(profile)
+	if err != nil {
+		result.Stderr += fmt.Sprintf("\nError reading coverage profile: %v", err)
+		return
+	}
+	report.Files, err = coverage.ParseGoProfile(data, goModulePath+"/", files)
+	if err != nil {
+		result.Stderr += fmt.Sprintf("\nError parsing coverage profile: %v", err)
+	}
+}
+
+// pythonCoverageData is the coverage written by pythonInstrumentBootstrap
+type pythonCoverageData struct {
+	Tool  string `json:"tool"`
+	Files []struct {
+		Path       string `json:"path"`
+		Statements []int  `json:"statements"`
+		Executed   []int  `json:"executed"`
+	} `json:"files"`
+}
+
+// collectPythonCoverage summarizes the coverage data written to path
+func collectPythonCoverage(path string, result *domain.ExecutionResult) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		result.Coverage = &domain.CoverageReport{}
+		return
+	}
+	var data pythonCoverageData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		result.Stderr += fmt.Sprintf("\nError reading coverage data: %v", err)
+		return
+	}
+
+	report := &domain.CoverageReport{Tool: data.Tool}
+	for _, file := range data.Files {
+		report.Files = append(report.Files, coverage.FromLines(file.Path, file.Statements, file.Executed))
+	}
+	result.Coverage = report
+}
@@ -0,0 +1,223 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+const (
+	// proxyDialTimeout bounds connecting to an allowed destination
+	proxyDialTimeout = 10 * time.Second
+
+	// proxyMaxConnections caps how many attempts one execution records
+	proxyMaxConnections = 1000
+)
+
+// egressProxy is an HTTP proxy that only connects to allowed destinations.
+// It tunnels CONNECT requests, which HTTPS clients send, and forwards
+// plain HTTP requests with one request per connection. Every attempt is
+// recorded.
+type egressProxy struct {
+	allowlist []string
+
+	mu          sync.Mutex
+	listener    net.Listener
+	conns       map[net.Conn]bool
+	connections []domain.Connection
+	dropped     int
+}
+
+// newEgressProxy creates a proxy for the destinations in allowlist
+func newEgressProxy(allowlist []string) *egressProxy {
+	return &egressProxy{allowlist: allowlist, conns: map[net.Conn]bool{}}
+}
+
+// Start serves proxy clients on listener in the background until Close
+// is called
+func (p *egressProxy) Start(listener net.Listener) {
+	p.mu.Lock()
+	p.listener = listener
+	p.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go p.handle(conn)
+		}
+	}()
+}
+
+// Close stops the proxy and drops the connections it holds open
+func (p *egressProxy) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.listener != nil {
+		p.listener.Close()
+	}
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.conns = nil
+}
+
+// Connections returns the attempts recorded so far
+func (p *egressProxy) Connections() []domain.Connection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	connections := append([]domain.Connection{}, p.connections...)
+	if p.dropped > 0 {
+		connections = append(connections, domain.Connection{
+			Error: fmt.Sprintf("%d more connections were not recorded", p.dropped),
+		})
+	}
+	return connections
+}
+
+// handle serves one client connection
+func (p *egressProxy) handle(client net.Conn) {
+	if !p.track(client) {
+		client.Close()
+		return
+	}
+	defer p.untrack(client)
+
+	reader := bufio.NewReader(client)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+
+	tunnel := req.Method == http.MethodConnect
+	address := req.Host
+	if !tunnel {
+		address = req.URL.Host
+		if req.URL.Scheme != "http" || address == "" {
+			writeProxyError(client, http.StatusBadRequest, "only http:// URLs and CONNECT are proxied")
+			return
+		}
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "80"
+	}
+
+	attempt := domain.Connection{Host: host, Port: port, Allowed: p.allowed(host, port)}
+	if !attempt.Allowed {
+		p.record(attempt)
+		writeProxyError(client, http.StatusForbidden, "destination not in the allowlist")
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), proxyDialTimeout)
+	if err != nil {
+		attempt.Error = err.Error()
+		p.record(attempt)
+		writeProxyError(client, http.StatusBadGateway, err.Error())
+		return
+	}
+	p.record(attempt)
+	if !p.track(upstream) {
+		upstream.Close()
+		return
+	}
+	defer p.untrack(upstream)
+
+	if tunnel {
+		io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	} else {
+		req.Close = true
+		req.Header.Del("Proxy-Connection")
+		req.Header.Del("Proxy-Authorization")
+		if err := req.Write(upstream); err != nil {
+			return
+		}
+	}
+
+	// Relay both ways, including anything the client sent after its
+	// request line, until either side closes
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// allowed reports whether host:port matches the allowlist. Entries are a
+// host, which allows any port, host:port, or *.domain, which allows the
+// subdomains of domain.
+func (p *egressProxy) allowed(host, port string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range p.allowlist {
+		entry = strings.ToLower(entry)
+		entryHost, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			entryHost, entryPort = entry, ""
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(entryHost, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == strings.Trim(entryHost, "[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// record adds an attempt to the log
+func (p *egressProxy) record(attempt domain.Connection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.connections) >= proxyMaxConnections {
+		p.dropped++
+		return
+	}
+	p.connections = append(p.connections, attempt)
+}
+
+// track registers conn so that Close can drop it. It returns false once
+// the proxy is closed.
+func (p *egressProxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conns == nil {
+		return false
+	}
+	p.conns[conn] = true
+	return true
+}
+
+// untrack closes conn and forgets it
+func (p *egressProxy) untrack(conn net.Conn) {
+	conn.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, conn)
+}
+
+// writeProxyError answers a proxy client with an error status
+func writeProxyError(conn net.Conn, status int, message string) {
+	fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		status, http.StatusText(status), len(message)+1, message+"\n")
+}
@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// startTestProxy serves a proxy for allowlist on a loopback port until the
+// test ends
+func startTestProxy(t *testing.T, allowlist []string) (*egressProxy, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	proxy := newEgressProxy(allowlist)
+	proxy.Start(listener)
+	t.Cleanup(proxy.Close)
+	return proxy, listener.Addr().String()
+}
+
+// startTestUpstream serves a fixed body on a loopback port until the test
+// ends
+func startTestUpstream(t *testing.T) (host, port string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "upstream")
+	}))
+	t.Cleanup(server.Close)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
+
+// connectThrough tunnels a GET request to target through the proxy at
+// proxyAddr. It returns the status of the CONNECT, and the body served
+// through the tunnel when it was established.
+func connectThrough(t *testing.T, proxyAddr, target string) (int, string) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", proxyAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialing proxy: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		t.Fatalf("reading CONNECT response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, ""
+	}
+
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target)
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("reading tunneled response: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading tunneled body: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestEgressProxyConnect(t *testing.T) {
+	host, port := startTestUpstream(t)
+	target := net.JoinHostPort(host, port)
+
+	// A loopback port that nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedTarget := closed.Addr().String()
+	_, closedPort, _ := net.SplitHostPort(closedTarget)
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		target    string
+		status    int
+		body      string
+		logged    domain.Connection
+		dialError bool
+	}{
+		{
+			name:      "host allows any port",
+			allowlist: []string{host},
+			target:    target,
+			status:    http.StatusOK,
+			body:      "upstream",
+			logged:    domain.Connection{Host: host, Port: port, Allowed: true},
+		},
+		{
+			name:      "host and port",
+			allowlist: []string{target},
+			target:    target,
+			status:    http.StatusOK,
+			body:      "upstream",
+			logged:    domain.Connection{Host: host, Port: port, Allowed: true},
+		},
+		{
+			name:      "other port",
+			allowlist: []string{net.JoinHostPort(host, "1")},
+			target:    target,
+			status:    http.StatusForbidden,
+			logged:    domain.Connection{Host: host, Port: port},
+		},
+		{
+			name:      "wildcard does not match an address",
+			allowlist: []string{"*.example.com"},
+			target:    target,
+			status:    http.StatusForbidden,
+			logged:    domain.Connection{Host: host, Port: port},
+		},
+		{
+			name:   "empty allowlist",
+			target: target,
+			status: http.StatusForbidden,
+			logged: domain.Connection{Host: host, Port: port},
+		},
+		{
+			name:      "allowed but unreachable",
+			allowlist: []string{closedTarget},
+			target:    closedTarget,
+			status:    http.StatusBadGateway,
+			logged:    domain.Connection{Host: host, Port: closedPort, Allowed: true},
+			dialError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, proxyAddr := startTestProxy(t, tt.allowlist)
+
+			status, body := connectThrough(t, proxyAddr, tt.target)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+
+			connections := proxy.Connections()
+			if len(connections) != 1 {
+				t.Fatalf("logged %d connections, want 1: %+v", len(connections), connections)
+			}
+			got := connections[0]
+			if (got.Error != "") != tt.dialError {
+				t.Errorf("logged error %q, want an error: %v", got.Error, tt.dialError)
+			}
+			got.Error = ""
+			if got != tt.logged {
+				t.Errorf("logged %+v, want %+v", got, tt.logged)
+			}
+		})
+	}
+}
+
+func TestEgressProxyForwardsPlainHTTP(t *testing.T) {
+	host, port := startTestUpstream(t)
+	target := net.JoinHostPort(host, port)
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		status    int
+		body      string
+	}{
+		{name: "allowed", allowlist: []string{target}, status: http.StatusOK, body: "upstream"},
+		{name: "denied", allowlist: []string{"example.com"}, status: http.StatusForbidden, body: "destination not in the allowlist\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, proxyAddr := startTestProxy(t, tt.allowlist)
+			client := &http.Client{
+				Timeout:   10 * time.Second,
+				Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: proxyAddr})},
+			}
+
+			resp, err := client.Get("http://" + target + "/")
+			if err != nil {
+				t.Fatalf("GET through proxy: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status || string(body) != tt.body {
+				t.Errorf("got %d %q, want %d %q", resp.StatusCode, body, tt.status, tt.body)
+			}
+
+			want := domain.Connection{Host: host, Port: port, Allowed: tt.status == http.StatusOK}
+			if connections := proxy.Connections(); len(connections) != 1 || connections[0] != want {
+				t.Errorf("logged %+v, want [%+v]", connections, want)
+			}
+		})
+	}
+}
+
+func TestEgressProxyAllowed(t *testing.T) {
+	tests := []struct {
+		allowlist []string
+		host      string
+		port      string
+		want      bool
+	}{
+		{[]string{"pypi.org"}, "pypi.org", "443", true},
+		{[]string{"pypi.org"}, "PyPI.org.", "80", true},
+		{[]string{"pypi.org"}, "files.pypi.org", "443", false},
+		{[]string{"pypi.org:443"}, "pypi.org", "443", true},
+		{[]string{"pypi.org:443"}, "pypi.org", "80", false},
+		{[]string{"*.pythonhosted.org"}, "files.pythonhosted.org", "443", true},
+		{[]string{"*.pythonhosted.org"}, "pythonhosted.org", "443", false},
+		{[]string{"*.pythonhosted.org"}, "evilpythonhosted.org", "443", false},
+		{[]string{"[::1]:8080"}, "::1", "8080", true},
+		{[]string{"example.com", "pypi.org"}, "pypi.org", "443", true},
+		{nil, "pypi.org", "443", false},
+	}
+	for _, tt := range tests {
+		proxy := newEgressProxy(tt.allowlist)
+		if got := proxy.allowed(tt.host, tt.port); got != tt.want {
+			t.Errorf("allowed(%q, %q) with %q = %v, want %v", tt.host, tt.port, tt.allowlist, got, tt.want)
+		}
+	}
+}
+
+func TestEgressProxyClosed(t *testing.T) {
+	host, _ := startTestUpstream(t)
+	proxy, proxyAddr := startTestProxy(t, []string{host})
+	proxy.Close()
+
+	conn, err := net.DialTimeout("tcp", proxyAddr, time.Second)
+	if err == nil {
+		conn.Close()
+		t.Fatalf("proxy still accepts connections after Close")
+	}
+	if connections := proxy.Connections(); len(connections) != 0 {
+		t.Errorf("logged %+v for a closed proxy", connections)
+	}
+}
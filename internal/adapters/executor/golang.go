@@ -1,23 +1,64 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/aravi/code_execution_mcp/internal/config"
 	"github.com/aravi/code_execution_mcp/internal/core/domain"
 	"github.com/aravi/code_execution_mcp/internal/core/ports"
 )
 
 // GolangExecutor implements CodeExecutor for Go code
-type GolangExecutor struct{}
+type GolangExecutor struct {
+	cache    *BinaryCache
+	modules  config.ModulesConfig
+	env      []string
+	policy   *goPolicy
+	sandbox  *Sandbox
+	observer ports.Observer
 
-// NewGolangExecutor creates a new Go executor
-func NewGolangExecutor() ports.CodeExecutor {
-	return &GolangExecutor{}
+	toolchainOnce sync.Once
+	toolchain     []byte
+	toolchainErr  error
+}
+
+// NewGolangExecutor creates a new Go executor that checks code against
+// policy before building it, runs the binary in sandbox, and reports its
+// steps to observer
+func NewGolangExecutor(cfg config.GolangConfig, policy config.GoPolicy, sandbox *Sandbox, observer ports.Observer) ports.CodeExecutor {
+	e := &GolangExecutor{
+		modules:  cfg.Modules,
+		env:      goBuildEnv(cfg.Modules),
+		policy:   newGoPolicy(policy),
+		sandbox:  sandbox,
+		observer: observerOrNop(observer),
+	}
+	if cfg.BuildCache.Enabled {
+		cache, err := NewBinaryCache(cfg.BuildCache.Dir, cfg.BuildCache.MaxSizeMB*1024*1024)
+		if err != nil {
+			slog.Warn("Go build cache disabled", "error", err)
+		} else {
+			// The run's temporary directory, binary included, is handed
+			// to the user the run switches to
+			cache.copyOut = sandbox.switchesUser()
+			e.cache = cache
+		}
+	}
+	return e
 }
 
 // Supports checks if this executor supports the given language
@@ -27,63 +68,345 @@ func (e *GolangExecutor) Supports(language string) bool {
 
 // Execute runs Go code
 func (e *GolangExecutor) Execute(ctx context.Context, req domain.ExecutionRequest) (*domain.ExecutionResult, error) {
-	if strings.TrimSpace(req.Code) == "" {
-		return &domain.ExecutionResult{
-			IsError:   true,
-			ErrorType: domain.ValidationError,
-			Stderr:    "Go code cannot be empty",
-		}, nil
+	prepared, rejected, err := e.prepare(ctx, req)
+	if prepared == nil {
+		return rejected, err
 	}
+	defer prepared.Cleanup()
 
-	// Validate that the code has required structure
-	if !strings.Contains(req.Code, "package main") {
-		return &domain.ExecutionResult{
-			IsError:   true,
-			ErrorType: domain.ValidationError,
-			Stderr:    "Go code must include 'package main'",
-		}, nil
+	return runPrepared(e.observer, prepared, req)
+}
+
+// Prepare compiles Go code and returns the binary's process without
+// starting it. In test mode the binary is the compiled test binary. A
+// compile failure is returned as the rejection result.
+func (e *GolangExecutor) Prepare(ctx context.Context, req domain.ExecutionRequest) (ports.PreparedProcess, *domain.ExecutionResult, error) {
+	return exposePrepared(e.prepare(ctx, req))
+}
+
+// prepare compiles Go code and builds the binary's process
+func (e *GolangExecutor) prepare(ctx context.Context, req domain.ExecutionRequest) (*PreparedCommand, *domain.ExecutionResult, error) {
+	span := startStep(e.observer, ctx, "policy.check", req.Language)
+	rejected := validateGoRequest(req, e.policy)
+	endStep(span, rejected)
+	if rejected != nil {
+		return nil, rejected, nil
 	}
+	testing := req.Mode == domain.ModeTest
 
-	if !strings.Contains(req.Code, "func main()") {
-		return &domain.ExecutionResult{
+	toolchain, err := e.toolchainFingerprint()
+	if err != nil {
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
-			ErrorType: domain.ValidationError,
-			Stderr:    "Go code must include 'func main()'",
+			ErrorType: domain.SystemError,
+			Stderr:    fmt.Sprintf("Error inspecting Go toolchain: %v", err),
 		}, nil
 	}
 
-	timeout := getTimeout(req.Timeout, 60)
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
 	// Create a temporary directory for the Go module
 	tmpDir, err := os.MkdirTemp("", "mcp_golang_*")
 	if err != nil {
-		return &domain.ExecutionResult{
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
 			ErrorType: domain.SystemError,
 			Stderr:    fmt.Sprintf("Error creating temp directory: %v", err),
 		}, nil
 	}
-	defer os.RemoveAll(tmpDir)
 
-	// Write the Go file
-	goFile := filepath.Join(tmpDir, "main.go")
-	if err := os.WriteFile(goFile, []byte(req.Code), 0644); err != nil {
-		return &domain.ExecutionResult{
-			IsError:   true,
-			ErrorType: domain.SystemError,
-			Stderr:    fmt.Sprintf("Error writing Go file: %v", err),
-		}, nil
+	timeout := getTimeout(req.Timeout, 60)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	cleanup := func() {
+		cancel()
+		os.RemoveAll(tmpDir)
 	}
 
-	// Use 'go run' for execution
-	cmd := exec.CommandContext(ctx, "go", "run", goFile)
+	// Write the Go module
+	files := map[string][]byte{
+		"go.mod": goModFile(toolchain),
+	}
+	if strings.TrimSpace(req.Code) != "" {
+		files["main.go"] = []byte(req.Code)
+	}
+	buildArgs := []string{"build"}
+	if testing {
+		files["main_test.go"] = []byte(req.TestCode)
+		buildArgs = []string{"test", "-c"}
+	}
+	if req.Coverage {
+		buildArgs = append(buildArgs, "-cover")
+	}
+	profiling := req.Profile && testing
+	if req.Profile && !testing {
+		var code string
+		if code, profiling = instrumentGoMain(req.Code); profiling {
+			files["main.go"] = []byte(code)
+			files["mcp_profile.go"] = []byte(goProfileHarness)
+		}
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			cleanup()
+			return nil, &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("Error writing Go file: %v", err),
+			}, nil
+		}
+	}
+
+	span = startStep(e.observer, ctx, "compile", req.Language)
+	binary, compiled := e.build(ctx, tmpDir, files, toolchain, buildArgs)
+	span.SetAttributes(slog.String("go.build_cache", string(compiled.BuildCache)))
+	endStep(span, compiled)
+	if compiled.IsError {
+		cleanup()
+		return nil, compiled, nil
+	}
+
+	// Run the compiled binary. Test binaries take their flags before the
+	// user's arguments, which may add flags such as -test.run.
+	var args, env []string
+	if testing {
+		args = append(args, "-test.v")
+	}
+	coverDir := filepath.Join(tmpDir, "covdata")
+	if req.Coverage {
+		if err := os.Mkdir(coverDir, 0755); err != nil {
+			cleanup()
+			return nil, &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("Error creating coverage directory: %v", err),
+			}, nil
+		}
+		if testing {
+			args = append(args, "-test.gocoverdir="+coverDir)
+		} else {
+			env = append(env, "GOCOVERDIR="+coverDir)
+		}
+	}
+	if profiling {
+		cpu, heap := filepath.Join(tmpDir, "cpu.pprof"), filepath.Join(tmpDir, "heap.pprof")
+		if testing {
+			args = append(args, "-test.cpuprofile="+cpu, "-test.memprofile="+heap)
+		} else {
+			env = append(env, "MCP_PROFILE_CPU="+cpu, "MCP_PROFILE_HEAP="+heap)
+		}
+	}
+	args = append(args, req.Args...)
+
+	cmd := exec.CommandContext(ctx, binary, args...)
 	if req.WorkingDir != "" {
 		cmd.Dir = req.WorkingDir
 	} else {
 		cmd.Dir = tmpDir
 	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	prepared := &PreparedCommand{
+		Cmd:             cmd,
+		Ctx:             ctx,
+		CompileDuration: compiled.CompileDuration,
+		BuildCache:      compiled.BuildCache,
+		Scratch:         []string{tmpDir},
+		Cleanup:         cleanup,
+	}
+	switch {
+	case profiling:
+		prepared.Collect = func(result *domain.ExecutionResult) {
+			e.collectGoProfile(binary, tmpDir, result)
+		}
+	case req.Coverage:
+		prepared.Collect = func(result *domain.ExecutionResult) {
+			e.collectGoCoverage(coverDir, files, result)
+		}
+	}
+	if rejected := e.sandbox.Apply(prepared, req); rejected != nil {
+		prepared.Cleanup()
+		return nil, rejected, nil
+	}
+	return prepared, nil, nil
+}
+
+// validateGoRequest checks the structure of a Go request, parses its code
+// and applies policy when it is not nil. It returns the rejection result,
+// or nil when the request may be built.
+func validateGoRequest(req domain.ExecutionRequest, policy *goPolicy) *domain.ExecutionResult {
+	reject := func(message string) *domain.ExecutionResult {
+		return &domain.ExecutionResult{
+			IsError:   true,
+			ErrorType: domain.ValidationError,
+			Stderr:    message,
+		}
+	}
+
+	if req.Profile && req.Coverage {
+		return reject("Profiling and coverage cannot be combined; coverage instrumentation skews the profile")
+	}
+
+	testing := false
+	switch req.Mode {
+	case "", domain.ModeRun:
+		if strings.TrimSpace(req.Code) == "" {
+			return reject("Go code cannot be empty")
+		}
+	case domain.ModeTest:
+		if strings.TrimSpace(req.TestCode) == "" {
+			return reject("Test mode requires test code")
+		}
+		testing = true
+	default:
+		return reject(fmt.Sprintf("Unknown mode %q; use %q or %q", req.Mode, domain.ModeRun, domain.ModeTest))
+	}
+
+	type source struct{ label, code string }
+	sources := []source{{"code", req.Code}}
+	if testing {
+		sources = append(sources, source{"test code", req.TestCode})
+	}
+	for _, src := range sources {
+		if strings.TrimSpace(src.code) == "" {
+			continue
+		}
+
+		// Syntax errors are left to the compiler, which reports them along
+		// with its other diagnostics. The partial tree is still checked.
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "main.go", src.code, parser.SkipObjectResolution)
+		if err == nil && !testing {
+			if file.Name.Name != "main" {
+				return reject(fmt.Sprintf("Go code must be in package main, not package %s", file.Name.Name))
+			}
+			if mainFunc(file) == nil {
+				return reject("Go code must declare func main()")
+			}
+		}
+
+		if policy == nil {
+			continue
+		}
+		if violations := policy.Check(fset, file, src.code); len(violations) > 0 {
+			if testing {
+				for i := range violations {
+					violations[i].Message = src.label + ": " + violations[i].Message
+				}
+			}
+			return policyRejection(src.code, violations)
+		}
+	}
+	return nil
+}
+
+// build compiles the files in dir with the go subcommand and flags in
+// args, and returns the path of the binary. The returned result carries
+// the compile time and cache status; it is an error result when
+// compilation failed.
+func (e *GolangExecutor) build(ctx context.Context, dir string, files map[string][]byte, toolchain []byte, args []string) (string, *domain.ExecutionResult) {
+	startTime := time.Now()
+	status := &domain.ExecutionResult{}
+
+	var key string
+	output := filepath.Join(dir, "main"+exeSuffix())
+	if e.cache != nil {
+		// Without resolved modules the key could not tell a new module
+		// version apart, so the build goes uncached and reports the error
+		if resolved, err := e.resolveModules(ctx, dir); err != nil {
+			slog.Debug("Skipping the Go build cache", "error", err)
+		} else {
+			keyed := maps.Clone(files)
+			maps.Copy(keyed, resolved)
+			key = e.cache.Key(keyed, toolchain, args)
+			if path, ok := e.cache.Lookup(key, dir); ok {
+				status.BuildCache = domain.CacheHit
+				status.CompileDuration = time.Since(startTime)
+				return path, status
+			}
+			status.BuildCache = domain.CacheMiss
+			output = e.cache.TempPath(key)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "go", append(append([]string{}, args...), "-o", output, ".")...)
+	cmd.Dir = dir
+	cmd.Env = e.env
+
+	result, err := executeCommand(ctx, cmd, "")
+	if err != nil || result.IsError {
+		os.Remove(output)
+		if result == nil {
+			result = &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("Error building Go code: %v", err),
+			}
+		}
+		// A build that ran and failed is the code's fault, not the run's
+		if result.ErrorType == domain.RuntimeError {
+			result.ErrorType = domain.CompilationError
+		}
+		result.CompileDuration = result.Duration
+		result.BuildCache = status.BuildCache
+		return "", result
+	}
+
+	if key != "" {
+		stored, err := e.cache.Store(key, output, dir)
+		if err != nil {
+			return "", &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    err.Error(),
+			}
+		}
+		output = stored
+	}
+
+	status.CompileDuration = time.Since(startTime)
+	return output, status
+}
+
+// resolveModules resolves the modules that the code in dir imports, as the
+// build would, and returns the go.mod and go.sum that pin their versions.
+// The generated go.mod requires nothing, so the source alone does not say
+// which versions a build links against.
+func (e *GolangExecutor) resolveModules(ctx context.Context, dir string) (map[string][]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-deps", "-test", ".")
+	cmd.Dir = dir
+	cmd.Env = e.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, stderr.String())
+	}
+
+	resolved := make(map[string][]byte)
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		resolved[name] = content
+	}
+	return resolved, nil
+}
 
-	return executeCommand(cmd)
+// toolchainFingerprint describes the Go toolchain and build environment so
+// that cached binaries are invalidated when either changes
+func (e *GolangExecutor) toolchainFingerprint() ([]byte, error) {
+	e.toolchainOnce.Do(func() {
+		cmd := exec.Command("go", "env", "-json",
+			"GOVERSION", "GOOS", "GOARCH", "CGO_ENABLED", "GOFLAGS", "GOEXPERIMENT", "GOAMD64", "GOARM64")
+		cmd.Env = e.env
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			e.toolchainErr = fmt.Errorf("go env: %v: %s", err, stderr.String())
+			return
+		}
+		e.toolchain = out
+	})
+	return e.toolchain, e.toolchainErr
 }
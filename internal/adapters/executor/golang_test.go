@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"archive/zip"
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// publishModule adds a version of example.com/greet to the GOPROXY file
+// layout under proxy
+func publishModule(t *testing.T, proxy, version string) {
+	t.Helper()
+	dir := filepath.Join(proxy, "example.com", "greet", "@v")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	goMod := "module example.com/greet\n"
+	source := "package greet\n\nconst Version = \"" + version + "\"\n"
+	f, err := os.Create(filepath.Join(dir, version+".zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{"go.mod": goMod, "greet.go": source} {
+		w, err := zw.Create("example.com/greet@" + version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	writes := map[string]string{
+		version + ".mod":  goMod,
+		version + ".info": `{"Version":"` + version + `"}`,
+	}
+	for name, content := range writes {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, _ := os.ReadFile(filepath.Join(dir, "list"))
+	list = append(list, version+"\n"...)
+	if err := os.WriteFile(filepath.Join(dir, "list"), list, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildCacheKeyFollowsModuleVersions(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skipf("no go toolchain: %v", err)
+	}
+
+	proxy := t.TempDir()
+	e := &GolangExecutor{
+		env: append(goBuildEnv(config.ModulesConfig{
+			GoCache:    t.TempDir(),
+			GoModCache: t.TempDir(),
+			Offline:    true,
+			MirrorDir:  proxy,
+		}), "GOFLAGS=-mod=mod -modcacherw"),
+	}
+	cache := &BinaryCache{}
+	files := map[string][]byte{
+		"go.mod":  []byte("module " + goModulePath + "\n"),
+		"main.go": []byte("package main\n\nimport \"example.com/greet\"\n\nfunc main() { println(greet.Version) }\n"),
+	}
+
+	key := func() string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		resolved, err := e.resolveModules(context.Background(), dir)
+		if err != nil {
+			t.Fatalf("resolveModules: %v", err)
+		}
+		if !strings.Contains(string(resolved["go.mod"]), "example.com/greet") {
+			t.Fatalf("resolved go.mod does not require example.com/greet:\n%s", resolved["go.mod"])
+		}
+		keyed := maps.Clone(files)
+		maps.Copy(keyed, resolved)
+		return cache.Key(keyed, nil, []string{"build"})
+	}
+
+	publishModule(t, proxy, "v1.0.0")
+	first := key()
+	if again := key(); again != first {
+		t.Errorf("key changed without a new module version")
+	}
+
+	publishModule(t, proxy, "v1.1.0")
+	if key() == first {
+		t.Errorf("key did not change after a new module version was published")
+	}
+}
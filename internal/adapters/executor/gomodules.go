@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// goBuildEnv returns the environment for go commands: the server
+// environment with dedicated caches and, in offline mode, a proxy that
+// never reaches the network
+func goBuildEnv(cfg config.ModulesConfig) []string {
+	env := append(os.Environ(),
+		"GOCACHE="+cfg.GoCache,
+		"GOMODCACHE="+cfg.GoModCache,
+		"GOFLAGS=-mod=mod",
+	)
+
+	// Without a mirror, modules resolve from the download cache, which
+	// holds the modules ListModules reports
+	if cfg.Offline {
+		proxy := fileURL(filepath.Join(cfg.GoModCache, "cache", "download"))
+		if cfg.MirrorDir != "" {
+			proxy = fileURL(cfg.MirrorDir)
+		}
+		env = append(env,
+			"GOPROXY="+proxy,
+			"GOSUMDB=off",
+			"GOTOOLCHAIN=local",
+		)
+	}
+	return env
+}
+
+// goModulePath is the module path of every snippet
+const goModulePath = "snippet"
+
+// goModFile generates a go.mod for a snippet. The go directive follows the
+// installed toolchain so that language features match what it supports.
+func goModFile(toolchain []byte) []byte {
+	var env struct{ GOVERSION string }
+	_ = json.Unmarshal(toolchain, &env)
+
+	content := "module " + goModulePath + "\n"
+	version := strings.TrimPrefix(env.GOVERSION, "go")
+	if version != "" && !strings.ContainsAny(version, " -") {
+		content += "\ngo " + version + "\n"
+	}
+	return []byte(content)
+}
+
+// ListModules lists modules in the offline mirror, or in the module
+// download cache when no mirror is configured. Both use the GOPROXY file
+// layout of <module>/@v/<version>.zip.
+func (e *GolangExecutor) ListModules(ctx context.Context) ([]domain.Module, error) {
+	root := e.modules.MirrorDir
+	if root == "" {
+		root = filepath.Join(e.modules.GoModCache, "cache", "download")
+	}
+
+	var modules []domain.Module
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.IsDir() || d.Name() != "@v" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return nil
+		}
+		versions := zipVersions(path)
+		if len(versions) > 0 {
+			modules = append(modules, domain.Module{
+				Path:     unescapeModulePath(filepath.ToSlash(rel)),
+				Versions: versions,
+			})
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing modules in %s: %w", root, err)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+	return modules, nil
+}
+
+// zipVersions returns the versions in an @v directory that have a module
+// zip, which is what an offline build needs
+func zipVersions(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".zip"); ok {
+			versions = append(versions, unescapeModulePath(name))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// unescapeModulePath reverses the module cache case encoding, where an
+// upper-case letter is stored as '!' followed by its lower-case form
+func unescapeModulePath(escaped string) string {
+	var b strings.Builder
+	bang := false
+	for _, r := range escaped {
+		switch {
+		case bang:
+			b.WriteString(strings.ToUpper(string(r)))
+			bang = false
+		case r == '!':
+			bang = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// compareVersions orders semantic versions such as v1.10.0 and v1.9.0-rc.1.
+// Pre-release versions sort before the release they precede.
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts := strings.Split(aCore, ".")
+	bParts := strings.Split(bCore, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// fileURL converts a directory path into a file:// URL for GOPROXY
+func fileURL(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	dir = filepath.ToSlash(dir)
+	if !strings.HasPrefix(dir, "/") {
+		// Windows drive paths need a leading slash: file:///C:/mirror
+		dir = "/" + dir
+	}
+	return "file://" + dir
+}
@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// Go policy rules, reported with each violation
+const (
+	ruleDeniedImport     = "denied_import"
+	ruleImportNotAllowed = "import_not_allowed"
+	ruleNonStdlibImport  = "non_stdlib_import"
+	ruleLinkname         = "linkname"
+	ruleCgo              = "cgo"
+)
+
+// goPolicy checks Go source files against a config.GoPolicy
+type goPolicy struct {
+	cfg config.GoPolicy
+}
+
+// newGoPolicy returns the checker for cfg, or nil when it is disabled
+func newGoPolicy(cfg config.GoPolicy) *goPolicy {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &goPolicy{cfg: cfg}
+}
+
+// Check returns the violations in one source file. file is its parse
+// tree, which may be partial when src has syntax errors.
+func (p *goPolicy) Check(fset *token.FileSet, file *ast.File, src string) []domain.PolicyViolation {
+	var violations []domain.PolicyViolation
+	report := func(pos token.Position, rule, message string) {
+		violations = append(violations, domain.PolicyViolation{
+			Rule:    rule,
+			Message: message,
+			Line:    pos.Line,
+			Column:  pos.Column,
+		})
+	}
+
+	if file != nil {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if rule, message := p.checkImport(path); rule != "" {
+				report(fset.Position(spec.Path.Pos()), rule, message)
+			}
+		}
+	}
+
+	// Directives are found with the scanner rather than the parse tree so
+	// that a syntax error earlier in the file cannot hide them
+	if p.cfg.DenyLinkname {
+		fset := token.NewFileSet()
+		var s scanner.Scanner
+		s.Init(fset.AddFile("", -1, len(src)), []byte(src), nil, scanner.ScanComments)
+		for {
+			pos, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if tok == token.COMMENT && strings.HasPrefix(lit, "//go:linkname") {
+				report(fset.Position(pos), ruleLinkname, "//go:linkname directives are not allowed")
+			}
+		}
+	}
+	return violations
+}
+
+// checkImport returns the rule an import path breaks and why, or an empty
+// rule when the import is allowed
+func (p *goPolicy) checkImport(path string) (string, string) {
+	if path == "C" {
+		if p.cfg.DenyCgo {
+			return ruleCgo, `cgo (import "C") is not allowed`
+		}
+		return "", ""
+	}
+	switch {
+	case matchesImport(path, p.cfg.DenyImports):
+		return ruleDeniedImport, fmt.Sprintf("import of %q is not allowed", path)
+	case len(p.cfg.AllowImports) > 0 && !matchesImport(path, p.cfg.AllowImports):
+		return ruleImportNotAllowed, fmt.Sprintf("%q is not in the allowed imports", path)
+	case p.cfg.StdlibOnly && !isStdlibImport(path):
+		return ruleNonStdlibImport, fmt.Sprintf("%q is not in the standard library", path)
+	}
+	return "", ""
+}
+
+// matchesImport reports whether path is one of prefixes or a package
+// below one of them
+func matchesImport(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdlibImport reports whether path names a standard library package.
+// Module paths start with a domain name, so their first element has a dot.
+func isStdlibImport(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
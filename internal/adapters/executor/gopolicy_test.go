@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// checkGo parses src as the Go executor does and returns the rules of the
+// violations p finds in it
+func checkGo(p *goPolicy, src string) []string {
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "main.go", src, parser.SkipObjectResolution)
+	var rules []string
+	for _, v := range p.Check(fset, file, src) {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestGoPolicyImports(t *testing.T) {
+	deny := newGoPolicy(config.GoPolicy{Enabled: true, DenyImports: []string{"os/exec", "syscall", "unsafe"}})
+	allow := newGoPolicy(config.GoPolicy{Enabled: true, AllowImports: []string{"fmt", "strings", "golang.org/x/exp"}})
+	stdlib := newGoPolicy(config.GoPolicy{Enabled: true, StdlibOnly: true})
+	cgo := newGoPolicy(config.GoPolicy{Enabled: true, DenyCgo: true})
+	tests := []struct {
+		name   string
+		policy *goPolicy
+		src    string
+		rules  []string
+	}{
+		{"allowed", deny, `package main; import "fmt"; func main() { fmt.Println() }`, nil},
+		{"denied", deny, `package main; import "os/exec"`, []string{ruleDeniedImport}},
+		{"denied below", deny, `package main; import "syscall/js"`, []string{ruleDeniedImport}},
+		{"prefix is not a parent", deny, `package main; import "os/execx"`, nil},
+		{"parent of a denied package", deny, `package main; import "os"`, nil},
+		{"renamed", deny, `package main; import x "os/exec"`, []string{ruleDeniedImport}},
+		{"blank", deny, `package main; import _ "unsafe"`, []string{ruleDeniedImport}},
+		{"dot", deny, `package main; import . "syscall"`, []string{ruleDeniedImport}},
+		{"grouped", deny, "package main\nimport (\n\t\"fmt\"\n\t\"os/exec\"\n\t\"unsafe\"\n)", []string{ruleDeniedImport, ruleDeniedImport}},
+
+		{"in allow list", allow, `package main; import ("fmt"; "strings")`, nil},
+		{"below allowed module", allow, `package main; import "golang.org/x/exp/slices"`, nil},
+		{"not in allow list", allow, `package main; import "net/http"`, []string{ruleImportNotAllowed}},
+
+		{"stdlib", stdlib, `package main; import ("net/http"; "encoding/json")`, nil},
+		{"module", stdlib, `package main; import "github.com/pkg/errors"`, []string{ruleNonStdlibImport}},
+		{"module with a dotted host", stdlib, `package main; import "gopkg.in/yaml.v3"`, []string{ruleNonStdlibImport}},
+
+		{"cgo denied", cgo, "package main\n// #include <stdio.h>\nimport \"C\"", []string{ruleCgo}},
+		{"cgo allowed", deny, "package main\nimport \"C\"", nil},
+		{"cgo is not an import path", allow, "package main\nimport \"C\"", nil},
+	}
+	for _, tt := range tests {
+		if rules := checkGo(tt.policy, tt.src); !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("%s: Check(%q) = %q, want %q", tt.name, tt.src, rules, tt.rules)
+		}
+	}
+}
+
+func TestGoPolicyLinkname(t *testing.T) {
+	p := newGoPolicy(config.GoPolicy{Enabled: true, DenyLinkname: true, DenyImports: []string{"unsafe"}})
+	tests := []struct {
+		name  string
+		src   string
+		rules []string
+	}{
+		{"directive", "package main\n\nimport _ \"unsafe\"\n\n//go:linkname now runtime.nanotime\nfunc now() int64\n", []string{ruleDeniedImport, ruleLinkname}},
+		{"after a syntax error", "package main\nfunc main() {\n//go:linkname x runtime.x\n", []string{ruleLinkname}},
+		{"mentioned in a comment", "package main\n// uses go:linkname elsewhere\n", nil},
+		{"in a string", "package main\nvar s = \"//go:linkname x y\"\n", nil},
+		{"spaced comment", "package main\n// go:linkname x y\n", nil},
+	}
+	for _, tt := range tests {
+		if rules := checkGo(p, tt.src); !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("%s: Check = %q, want %q", tt.name, rules, tt.rules)
+		}
+	}
+
+	allowed := newGoPolicy(config.GoPolicy{Enabled: true})
+	if rules := checkGo(allowed, "package main\n//go:linkname x y\n"); rules != nil {
+		t.Errorf("Check without deny_linkname = %q, want none", rules)
+	}
+}
+
+func TestGoPolicyPosition(t *testing.T) {
+	p := newGoPolicy(config.GoPolicy{Enabled: true, DenyImports: []string{"os/exec"}})
+	src := "package main\n\nimport (\n\t\"fmt\"\n\t\"os/exec\"\n)\n"
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "main.go", src, parser.SkipObjectResolution)
+	violations := p.Check(fset, file, src)
+	if len(violations) != 1 || violations[0].Line != 5 || violations[0].Column != 2 || violations[0].Message != `import of "os/exec" is not allowed` {
+		t.Errorf("Check = %+v, want the os/exec import on line 5, column 2", violations)
+	}
+}
+
+func TestGoPolicyDisabled(t *testing.T) {
+	if p := newGoPolicy(config.GoPolicy{DenyImports: []string{"os/exec"}}); p != nil {
+		t.Errorf("newGoPolicy of a disabled policy = %+v, want nil", p)
+	}
+}
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls, which have the same numbers on every architecture
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+
+	prSetNoNewPrivs = 38
+
+	// oPath is O_PATH, which package syscall lacks
+	oPath = 0x200000
+)
+
+// Landlock filesystem access rights
+const (
+	landlockExecute    = 1 << 0
+	landlockWriteFile  = 1 << 1
+	landlockReadFile   = 1 << 2
+	landlockReadDir    = 1 << 3
+	landlockRefer      = 1 << 13 // ABI 2
+	landlockTruncate   = 1 << 14 // ABI 3
+	landlockIoctlDev   = 1 << 15 // ABI 5
+	landlockABI1Rights = 1<<13 - 1
+
+	// landlockReadRights may be granted on read-only paths
+	landlockReadRights = landlockExecute | landlockReadFile | landlockReadDir
+
+	// landlockFileRights are the rights that apply to a file rather than
+	// a directory
+	landlockFileRights = landlockExecute | landlockWriteFile | landlockReadFile | landlockTruncate | landlockIoctlDev
+)
+
+// landlockRulesetAttr is struct landlock_ruleset_attr up to the filesystem
+// rights, which is all this sandbox handles
+type landlockRulesetAttr struct {
+	handledAccessFS uint64
+}
+
+// landlockPathBeneathAttr is the packed struct landlock_path_beneath_attr.
+// The kernel reads the first 12 bytes.
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+// landlockABI returns the Landlock ABI version the kernel supports
+func landlockABI() (int, error) {
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	switch errno {
+	case 0:
+		return int(abi), nil
+	case syscall.ENOSYS:
+		return 0, errors.New("this kernel does not support Landlock (Linux 5.13 or later is required)")
+	case syscall.EOPNOTSUPP:
+		return 0, errors.New("Landlock is disabled in this kernel; add it to the lsm= boot parameter")
+	default:
+		return 0, fmt.Errorf("probing Landlock: %w", errno)
+	}
+}
+
+// landlockHandledRights returns every filesystem right ABI version abi
+// knows about. Handling all of them denies whatever the rules don't grant.
+func landlockHandledRights(abi int) uint64 {
+	rights := uint64(landlockABI1Rights)
+	if abi >= 2 {
+		rights |= landlockRefer
+	}
+	if abi >= 3 {
+		rights |= landlockTruncate
+	}
+	if abi >= 5 {
+		rights |= landlockIoctlDev
+	}
+	return rights
+}
+
+// restrictFilesystem confines the calling thread with a Landlock ruleset
+// that allows reading and executing beneath readOnly and everything
+// beneath readWrite. Paths that don't exist are skipped.
+func restrictFilesystem(abi int, readOnly, readWrite []string) error {
+	handled := landlockHandledRights(abi)
+	attr := landlockRulesetAttr{handledAccessFS: handled}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("creating Landlock ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer syscall.Close(ruleset)
+
+	for _, path := range readOnly {
+		if err := addLandlockRule(ruleset, path, landlockReadRights); err != nil {
+			return err
+		}
+	}
+	for _, path := range readWrite {
+		if err := addLandlockRule(ruleset, path, handled); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("setting no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("enforcing Landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule grants access beneath path. Only file rights are granted
+// on a path that is not a directory.
+func addLandlockRule(ruleset int, path string, access uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("opening %s for the Landlock ruleset: %w", path, err)
+	}
+	defer syscall.Close(fd)
+
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("checking %s for the Landlock ruleset: %w", path, err)
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access &= landlockFileRights
+	}
+
+	rule := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(fd)}
+	if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("adding %s to the Landlock ruleset: %w", path, errno)
+	}
+	return nil
+}
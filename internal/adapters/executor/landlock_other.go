@@ -0,0 +1,18 @@
+//go:build !linux
+
+package executor
+
+import "errors"
+
+// errNoLandlock reports that Landlock is unavailable
+var errNoLandlock = errors.New("Landlock requires Linux")
+
+// landlockABI reports that Landlock is unavailable
+func landlockABI() (int, error) {
+	return 0, errNoLandlock
+}
+
+// restrictFilesystem fails: the filesystem sandbox relies on Landlock
+func restrictFilesystem(abi int, readOnly, readWrite []string) error {
+	return errNoLandlock
+}
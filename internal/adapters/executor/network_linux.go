@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// ifreqFlags is the kernel's struct ifreq as used by SIOCGIFFLAGS and
+// SIOCSIFFLAGS
+type ifreqFlags struct {
+	name  [syscall.IFNAMSIZ]byte
+	flags uint16
+	_     [22]byte
+}
+
+// networkNamespace moves the thread into a new, empty network namespace.
+// With loopback set the namespace's lo interface is brought up, and a
+// non-nil proxy is served on it and named in the proxy variables of the
+// command's environment.
+func networkNamespace(loopback bool, proxy *egressProxy) confinement {
+	return func(cmd *exec.Cmd) error {
+		if err := syscall.Unshare(syscall.CLONE_NEWNET); err != nil {
+			return fmt.Errorf("creating network namespace: %w", err)
+		}
+		if loopback {
+			if err := bringUpLoopback(); err != nil {
+				return fmt.Errorf("bringing up loopback: %w", err)
+			}
+		}
+		if proxy != nil {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return fmt.Errorf("starting egress proxy: %w", err)
+			}
+			proxy.Start(listener)
+
+			env := cmd.Env
+			if env == nil {
+				env = os.Environ()
+			}
+			cmd.Env = withProxyEnv(env, listener.Addr().String())
+		}
+		return nil
+	}
+}
+
+// probeNetworkNamespace checks that the server may create network
+// namespaces, using a thread that is discarded afterwards
+func probeNetworkNamespace() error {
+	done := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		done <- syscall.Unshare(syscall.CLONE_NEWNET)
+	}()
+	if err := <-done; err != nil {
+		return fmt.Errorf("creating network namespaces: %w", err)
+	}
+	return nil
+}
+
+// bringUpLoopback marks the lo interface of the current network namespace
+// as up
+func bringUpLoopback() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	var req ifreqFlags
+	copy(req.name[:], "lo")
+	if err := ioctl(fd, syscall.SIOCGIFFLAGS, unsafe.Pointer(&req)); err != nil {
+		return err
+	}
+	req.flags |= syscall.IFF_UP
+	return ioctl(fd, syscall.SIOCSIFFLAGS, unsafe.Pointer(&req))
+}
+
+// ioctl issues an ioctl whose argument is a pointer
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// TestNetworkHelperProcess is not a test: the namespace tests run the
+// test binary with it selected, as the confined process. It fetches the
+// URL in NETWORK_HELPER_URL, through $HTTP_PROXY when that is set, and
+// prints the status and body or the error.
+func TestNetworkHelperProcess(t *testing.T) {
+	target := os.Getenv("NETWORK_HELPER_URL")
+	if target == "" {
+		return
+	}
+	transport := &http.Transport{}
+	if proxy := os.Getenv("HTTP_PROXY"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			fmt.Printf("error: %v", err)
+			os.Exit(0)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+	resp, err := client.Get(target)
+	if err != nil {
+		fmt.Printf("error: %v", err)
+		os.Exit(0)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%d %s", resp.StatusCode, body)
+	os.Exit(0)
+}
+
+func TestNetworkNamespace(t *testing.T) {
+	if err := probeNetworkNamespace(); err != nil {
+		t.Skipf("network namespaces are unavailable: %v", err)
+	}
+	host, port := startTestUpstream(t)
+	target := net.JoinHostPort(host, port)
+
+	tests := []struct {
+		name      string
+		host      bool
+		loopback  bool
+		allowlist []string
+		proxied   bool
+		want      string
+		logged    []domain.Connection
+	}{
+		{
+			name: "host",
+			host: true,
+			want: "200 upstream",
+		},
+		{
+			name: "none",
+			want: "error: ",
+		},
+		{
+			name:     "loopback cannot reach the host's loopback",
+			loopback: true,
+			want:     "error: ",
+		},
+		{
+			name:      "allowlist reaches allowed hosts through the proxy",
+			loopback:  true,
+			allowlist: []string{target},
+			proxied:   true,
+			want:      "200 upstream",
+			logged:    []domain.Connection{{Host: host, Port: port, Allowed: true}},
+		},
+		{
+			name:      "allowlist denies other hosts",
+			loopback:  true,
+			allowlist: []string{"example.com"},
+			proxied:   true,
+			want:      "403 destination not in the allowlist\n",
+			logged:    []domain.Connection{{Host: host, Port: port}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestNetworkHelperProcess$")
+			cmd.Env = append(os.Environ(), "NETWORK_HELPER_URL=http://"+target+"/", "HTTP_PROXY=")
+			var stdout bytes.Buffer
+			cmd.Stdout = &stdout
+
+			var proxy *egressProxy
+			if tt.proxied {
+				proxy = newEgressProxy(tt.allowlist)
+				t.Cleanup(proxy.Close)
+			}
+			var steps []confinement
+			if !tt.host {
+				steps = append(steps, networkNamespace(tt.loopback, proxy))
+			}
+			if err := startConfined(cmd, steps); err != nil {
+				t.Fatalf("starting confined process: %v", err)
+			}
+			if err := cmd.Wait(); err != nil {
+				t.Fatalf("confined process failed: %v\n%s", err, stdout.String())
+			}
+
+			got := stdout.String()
+			if strings.HasSuffix(tt.want, ": ") {
+				if !strings.HasPrefix(got, tt.want) {
+					t.Errorf("got %q, want an error", got)
+				}
+			} else if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			proxyVar := ""
+			for _, entry := range cmd.Env {
+				if value, ok := strings.CutPrefix(entry, "HTTP_PROXY="); ok {
+					proxyVar = value
+				}
+			}
+			if tt.proxied != (proxyVar != "") {
+				t.Errorf("HTTP_PROXY = %q in the confined environment, want it set: %v", proxyVar, tt.proxied)
+			}
+
+			if proxy != nil {
+				connections := proxy.Connections()
+				if len(connections) != len(tt.logged) {
+					t.Fatalf("logged %+v, want %+v", connections, tt.logged)
+				}
+				for i := range connections {
+					if connections[i] != tt.logged[i] {
+						t.Errorf("logged %+v, want %+v", connections[i], tt.logged[i])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errNoNetworkNamespaces reports that network isolation is unavailable
+var errNoNetworkNamespaces = errors.New("network isolation requires Linux network namespaces")
+
+// networkNamespace fails: network isolation relies on Linux network
+// namespaces
+func networkNamespace(loopback bool, proxy *egressProxy) confinement {
+	return func(*exec.Cmd) error {
+		return errNoNetworkNamespaces
+	}
+}
+
+// probeNetworkNamespace reports that network namespaces are unavailable
+func probeNetworkNamespace() error {
+	return errNoNetworkNamespaces
+}
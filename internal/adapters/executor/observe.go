@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// nopObserver records nothing; it stands in for a nil observer
+type nopObserver struct{}
+
+// StartSpan returns a span that records nothing
+func (nopObserver) StartSpan(context.Context, string, ...slog.Attr) ports.Span { return nopSpan{} }
+
+// Logger returns the default logger
+func (nopObserver) Logger(context.Context) *slog.Logger { return slog.Default() }
+
+// nopSpan ignores every call
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...slog.Attr) {}
+func (nopSpan) SetError(string)            {}
+func (nopSpan) End()                       {}
+
+// observerOrNop returns observer, or one that records nothing when it is
+// nil
+func observerOrNop(observer ports.Observer) ports.Observer {
+	if observer == nil {
+		return nopObserver{}
+	}
+	return observer
+}
+
+// startStep starts the span timing one step of an execution
+func startStep(observer ports.Observer, ctx context.Context, name, language string) ports.Span {
+	return observer.StartSpan(ctx, name, slog.String("code.language", language))
+}
+
+// endStep records the outcome of a step and ends its span. A nil result
+// means the step succeeded.
+func endStep(span ports.Span, result *domain.ExecutionResult) {
+	if result != nil && result.IsError {
+		span.SetAttributes(slog.String("error.type", result.ErrorType.String()))
+		message, _, _ := strings.Cut(strings.TrimSpace(result.Stderr), "\n")
+		span.SetError(message)
+	}
+	span.End()
+}
+
+// endRun records the exit status of a process run in language on its
+// span and in the log of ctx, and ends the span
+func endRun(observer ports.Observer, ctx context.Context, span ports.Span, language string, result *domain.ExecutionResult) {
+	if result != nil {
+		span.SetAttributes(slog.Int("process.exit_code", result.ExitCode))
+		observer.Logger(ctx).Debug("Process exited", "language", language, "exit_code", result.ExitCode,
+			"error_type", result.ErrorType.String(), "duration_ms", result.Duration.Milliseconds())
+	}
+	endStep(span, result)
+}
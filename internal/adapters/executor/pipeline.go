@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// PipelineRunner implements ports.PipelineRunner by connecting the
+// processes prepared by the executors with OS pipes, so that data streams
+// between stages instead of being buffered in the server
+type PipelineRunner struct {
+	executors []ports.CodeExecutor
+	observer  ports.Observer
+}
+
+// NewPipelineRunner creates a pipeline runner over the given executors
+// that reports the stages it runs to observer
+func NewPipelineRunner(observer ports.Observer, executors ...ports.CodeExecutor) ports.PipelineRunner {
+	return &PipelineRunner{executors: executors, observer: observerOrNop(observer)}
+}
+
+// RunPipeline runs the stages concurrently. The first stage reads the
+// first request's Stdin; every other Stdin is ignored.
+func (r *PipelineRunner) RunPipeline(ctx context.Context, stages []domain.ExecutionRequest) (*domain.PipelineResult, error) {
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("pipeline has no stages")
+	}
+
+	// Prepare every stage before starting any, so that a validation or
+	// compile failure doesn't leave half a pipeline running
+	prepared := make([]ports.PreparedProcess, 0, len(stages))
+	defer func() {
+		for _, p := range prepared {
+			p.Cleanup()
+		}
+	}()
+	for i, stage := range stages {
+		preparer, err := r.preparerFor(stage.Language)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		p, rejected, err := preparer.Prepare(ctx, stage)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		if p == nil {
+			return rejectedPipeline(len(stages), i, rejected), nil
+		}
+		prepared = append(prepared, p)
+	}
+
+	// Connect each stage's stdout to the next stage's stdin. The parent's
+	// copies of the pipe ends are closed once the children hold them, so
+	// that readers see EOF when their writer exits.
+	stdins := make([]io.Reader, len(prepared))
+	stdouts := make([]io.Writer, len(prepared))
+	var pipeEnds []*os.File
+	closePipes := func() {
+		for _, f := range pipeEnds {
+			f.Close()
+		}
+	}
+	for i := 0; i < len(prepared)-1; i++ {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			closePipes()
+			return nil, fmt.Errorf("creating pipe: %w", err)
+		}
+		stdouts[i], stdins[i+1] = writer, reader
+		pipeEnds = append(pipeEnds, reader, writer)
+	}
+	if stages[0].Stdin != "" {
+		stdins[0] = strings.NewReader(stages[0].Stdin)
+	}
+	var stdout bytes.Buffer
+	stdouts[len(prepared)-1] = &stdout
+	stderrs := make([]bytes.Buffer, len(prepared))
+	for i, p := range prepared {
+		p.Connect(stdins[i], stdouts[i], &stderrs[i])
+	}
+
+	startTime := time.Now()
+	starts := make([]time.Time, len(prepared))
+	spans := make([]ports.Span, len(prepared))
+	for i, p := range prepared {
+		spans[i] = startStep(r.observer, p.Context(), "process.run", stages[i].Language)
+		spans[i].SetAttributes(slog.Int("pipeline.stage", i+1))
+		if err := p.Start(); err != nil {
+			spans[i].SetError(err.Error())
+			for _, span := range spans[:i+1] {
+				span.End()
+			}
+			closePipes()
+			for _, started := range prepared[:i] {
+				started.Kill()
+			}
+			return nil, fmt.Errorf("starting stage %d: %w", i+1, err)
+		}
+		starts[i] = time.Now()
+	}
+	closePipes()
+
+	// Wait for every stage concurrently so each duration ends when that
+	// stage exits, not when the stages before it do
+	waitErrs := make([]error, len(prepared))
+	durations := make([]time.Duration, len(prepared))
+	var wg sync.WaitGroup
+	for i, p := range prepared {
+		wg.Add(1)
+		go func(i int, p ports.PreparedProcess) {
+			defer wg.Done()
+			waitErrs[i] = p.Wait()
+			durations[i] = time.Since(starts[i])
+		}(i, p)
+	}
+	wg.Wait()
+
+	result := &domain.PipelineResult{Stages: make([]*domain.ExecutionResult, len(prepared))}
+	for i, p := range prepared {
+		stage := p.Result(waitErrs[i], "", stderrs[i].String(), durations[i])
+		endRun(r.observer, p.Context(), spans[i], stages[i].Language, stage)
+		result.Stages[i] = stage
+
+		// pipefail: the last stage to fail determines the outcome
+		if stage.IsError {
+			result.ExitCode = stage.ExitCode
+			result.IsError = true
+			result.ErrorType = stage.ErrorType
+		}
+	}
+	result.Stdout = stdout.String()
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// preparerFor finds the executor for language and checks that it can
+// hand back a process
+func (r *PipelineRunner) preparerFor(language string) (ports.CommandPreparer, error) {
+	for _, executor := range r.executors {
+		if !executor.Supports(language) {
+			continue
+		}
+		preparer, ok := executor.(ports.CommandPreparer)
+		if !ok {
+			return nil, fmt.Errorf("language %q cannot be used in a pipeline", language)
+		}
+		return preparer, nil
+	}
+	return nil, fmt.Errorf("unsupported language %q", language)
+}
+
+// rejectedPipeline reports a pipeline that never started because the
+// stage at index failed to prepare
+func rejectedPipeline(count, index int, rejected *domain.ExecutionResult) *domain.PipelineResult {
+	stages := make([]*domain.ExecutionResult, count)
+	stages[index] = rejected
+	return &domain.PipelineResult{
+		Stages:    stages,
+		ExitCode:  rejected.ExitCode,
+		IsError:   true,
+		ErrorType: rejected.ErrorType,
+		Duration:  rejected.Duration,
+	}
+}
@@ -0,0 +1,35 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// policyRejection builds the validation result for code that broke the
+// execution policy. Each violation is listed with the source line it
+// points at.
+func policyRejection(source string, violations []domain.PolicyViolation) *domain.ExecutionResult {
+	lines := strings.Split(source, "\n")
+
+	var stderr strings.Builder
+	stderr.WriteString("Rejected by the execution policy:\n")
+	for _, v := range violations {
+		if v.Line > 0 {
+			stderr.WriteString(fmt.Sprintf("- line %d, column %d: %s (%s)\n", v.Line, v.Column, v.Message, v.Rule))
+			if v.Line <= len(lines) {
+				stderr.WriteString("    " + strings.TrimSpace(lines[v.Line-1]) + "\n")
+			}
+		} else {
+			stderr.WriteString(fmt.Sprintf("- %s (%s)\n", v.Message, v.Rule))
+		}
+	}
+
+	return &domain.ExecutionResult{
+		IsError:    true,
+		ErrorType:  domain.ValidationError,
+		Stderr:     strings.TrimSuffix(stderr.String(), "\n"),
+		Violations: violations,
+	}
+}
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// PreparedCommand is a process that is ready to start, as the executors
+// and the sandbox set it up. Callers wire its standard streams, run it,
+// call Collect if set, and then call Cleanup.
+type PreparedCommand struct {
+	Cmd *exec.Cmd
+
+	// Ctx is the context Cmd was created with; it carries the timeout
+	Ctx context.Context
+
+	// CompileDuration and BuildCache describe the build step, if any
+	CompileDuration time.Duration
+	BuildCache      domain.CacheStatus
+
+	// Start, when set, starts Cmd in place of Cmd.Start, inside the
+	// confinement the executor set up for it
+	Start func() error
+
+	// Collect, when set, attaches what the process left behind, such as a
+	// profile, to its result. It is called after the process exits and
+	// before Cleanup.
+	Collect func(result *domain.ExecutionResult)
+
+	// Scratch lists the temporary files and directories made for the
+	// process, which are handed over to the user it runs as
+	Scratch []string
+
+	// Cleanup releases the timeout and any temporary files
+	Cleanup func()
+}
+
+// start starts the process, inside its confinement when it has one
+func (p *PreparedCommand) start() error {
+	// Don't wait for grandchildren holding the output pipes after a kill
+	p.Cmd.WaitDelay = waitDelay
+	if p.Start != nil {
+		return p.Start()
+	}
+	return p.Cmd.Start()
+}
+
+// result builds the result of the exited process from the error Wait
+// returned, and records the build step alongside the run
+func (p *PreparedCommand) result(err error, stdout, stderr string, duration time.Duration) *domain.ExecutionResult {
+	result := newResult(err, stdout, stderr, duration)
+	recordUsage(result, p.Cmd.ProcessState)
+	markTimeout(p.Ctx, result)
+	markOutOfMemory(p.Ctx, result)
+	if p.CompileDuration > 0 {
+		result.CompileDuration = p.CompileDuration
+		result.RunDuration = result.Duration
+		result.Duration += p.CompileDuration
+	}
+	result.BuildCache = p.BuildCache
+	if p.Collect != nil {
+		p.Collect(result)
+	}
+	return result
+}
+
+// preparedProcess exposes a PreparedCommand through ports.PreparedProcess
+type preparedProcess struct {
+	command *PreparedCommand
+}
+
+// exposePrepared returns the outcome of an executor's prepare step as the
+// ports.CommandPreparer interface returns it
+func exposePrepared(prepared *PreparedCommand, rejected *domain.ExecutionResult, err error) (ports.PreparedProcess, *domain.ExecutionResult, error) {
+	if prepared == nil {
+		return nil, rejected, err
+	}
+	return preparedProcess{prepared}, nil, nil
+}
+
+// Context returns the context the command was created with
+func (p preparedProcess) Context() context.Context {
+	return p.command.Ctx
+}
+
+// Connect sets the standard streams of the command
+func (p preparedProcess) Connect(stdin io.Reader, stdout, stderr io.Writer) {
+	if stdin != nil {
+		p.command.Cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		p.command.Cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		p.command.Cmd.Stderr = stderr
+	}
+}
+
+// Start starts the command inside its confinement
+func (p preparedProcess) Start() error {
+	return p.command.start()
+}
+
+// Wait waits for the command to exit
+func (p preparedProcess) Wait() error {
+	return p.command.Cmd.Wait()
+}
+
+// Kill stops the started command and waits for it
+func (p preparedProcess) Kill() {
+	p.command.Cmd.Process.Kill()
+	p.command.Cmd.Wait()
+}
+
+// Result builds the result of the exited command
+func (p preparedProcess) Result(err error, stdout, stderr string, duration time.Duration) *domain.ExecutionResult {
+	return p.command.result(err, stdout, stderr, duration)
+}
+
+// Cleanup releases what the command was prepared with
+func (p preparedProcess) Cleanup() {
+	p.command.Cleanup()
+}
@@ -0,0 +1,229 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+const (
+	// profileTopN is the number of functions listed per profile table
+	profileTopN = 20
+
+	// profileSummaryTimeout bounds the post-processing of a profile. The
+	// first use of go tool pprof builds it, which can take a while.
+	profileSummaryTimeout = 2 * time.Minute
+
+	// profiledMainName is what the user's main is renamed to so that the
+	// profiling harness can wrap it
+	profiledMainName = "mcpProfiledMain"
+)
+
+// goProfileHarness becomes the program's main when profiling. It writes a
+// CPU profile for the whole run and a heap profile on return or panic.
+// Imports are aliased so they cannot collide with the user's declarations.
+const goProfileHarness = `package main
+
+import (
+	mcpos "os"
+	mcpruntime "runtime"
+	mcppprof "runtime/pprof"
+)
+
+func main() {
+	if f, err := mcpos.Create(mcpos.Getenv("MCP_PROFILE_CPU")); err == nil {
+		mcppprof.StartCPUProfile(f)
+		defer f.Close()
+		defer mcppprof.StopCPUProfile()
+	}
+	defer func() {
+		f, err := mcpos.Create(mcpos.Getenv("MCP_PROFILE_HEAP"))
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		mcpruntime.GC()
+		mcppprof.WriteHeapProfile(f)
+	}()
+	` + profiledMainName + `()
+}
+`
+
+// instrumentGoMain renames the main function of code so that the profiling
+// harness can call it. The rename is done in place, so line numbers in
+// compiler errors and panics still match the submitted code. ok is false
+// when code has no main to wrap.
+func instrumentGoMain(code string) (string, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, parser.SkipObjectResolution)
+	if err != nil {
+		return code, false
+	}
+	fn := mainFunc(file)
+	if fn == nil {
+		return code, false
+	}
+	offset := fset.Position(fn.Name.Pos()).Offset
+	return code[:offset] + profiledMainName + code[offset+len("main"):], true
+}
+
+// mainFunc returns the declaration of the main function in file, or nil
+func mainFunc(file *ast.File) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return fn
+		}
+	}
+	return nil
+}
+
+// collectGoProfile summarizes the CPU and heap profiles written to dir by
+// the harness and attaches them to result as artifacts
+func (e *GolangExecutor) collectGoProfile(binary, dir string, result *domain.ExecutionResult) {
+	profile := &domain.Profile{Tool: "pprof"}
+	result.Profile = profile
+
+	kinds := []struct {
+		file, title string
+		args        []string
+	}{
+		{"cpu.pprof", "CPU time", nil},
+		{"heap.pprof", "Allocated memory", []string{"-sample_index=alloc_space"}},
+	}
+	for _, kind := range kinds {
+		path := filepath.Join(dir, kind.file)
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			profile.Warning = "The program exited before its profile was written. Profiles are written when main returns or panics, so os.Exit and log.Fatal skip them."
+			continue
+		}
+		result.Artifacts = append(result.Artifacts, domain.Artifact{
+			Name:     kind.file,
+			MIMEType: "application/octet-stream",
+			Data:     data,
+		})
+
+		args := append([]string{"tool", "pprof", "-top", fmt.Sprintf("-nodecount=%d", profileTopN)}, kind.args...)
+		out, err := e.goTool(append(args, binary, path)...)
+		if err != nil {
+			profile.Warning = fmt.Sprintf("Error summarizing %s: %v", kind.file, err)
+			continue
+		}
+		profile.Tables = append(profile.Tables, parsePprofTop(kind.title, out))
+	}
+}
+
+// goTool runs the go command with the build environment to post-process
+// a finished execution and returns its stdout
+func (e *GolangExecutor) goTool(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), profileSummaryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = e.env
+	return runQuiet(cmd)
+}
+
+// parsePprofTop converts the output of `pprof -top` for a harnessed
+// program into a table. The function name is the last column and may
+// contain spaces.
+func parsePprofTop(title string, out []byte) domain.ProfileTable {
+	table := domain.ProfileTable{
+		Title:   title,
+		Columns: []string{"Flat", "Flat%", "Cum", "Cum%", "Function"},
+	}
+	inRows := false
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 5 && fields[0] == "flat" && fields[1] == "flat%":
+			inRows = true
+		case inRows && len(fields) >= 6:
+			// Hide the harness and show the user's main under its own name
+			function := strings.Join(fields[5:], " ")
+			if function == "main.main" {
+				continue
+			}
+			function = strings.ReplaceAll(function, "main."+profiledMainName, "main.main")
+			table.Rows = append(table.Rows, []string{fields[0], fields[1], fields[3], fields[4], function})
+		case strings.HasPrefix(line, "Showing nodes"):
+			table.Title += " (" + strings.TrimPrefix(line, "Showing nodes accounting for ") + ")"
+		}
+	}
+	return table
+}
+
+// pythonProfileSummary prints the functions with the most self time from a
+// cProfile dump as JSON rows
+const pythonProfileSummary = `
+import json, os, pstats, sys
+
+stats = pstats.Stats(sys.argv[1]).stats
+rows = []
+for (filename, line, name), (cc, nc, tt, ct, _) in sorted(stats.items(), key=lambda kv: -kv[1][2])[:int(sys.argv[2])]:
+    if filename == "~" and line == 0:
+        where = name
+    else:
+        where = "%s:%d(%s)" % (os.path.basename(filename), line, name)
+    calls = str(nc) if nc == cc else "%d/%d" % (nc, cc)
+    rows.append([calls, "%.6f" % tt, "%.6f" % ct, where])
+json.dump(rows, sys.stdout)
+`
+
+// collectPythonProfile summarizes the cProfile dump at path and attaches it
+// to result as an artifact
+func collectPythonProfile(path string, result *domain.ExecutionResult) {
+	profile := &domain.Profile{Tool: "cProfile"}
+	result.Profile = profile
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		profile.Warning = "The interpreter exited before its profile was written. os._exit and fatal signals skip it."
+		return
+	}
+	result.Artifacts = append(result.Artifacts, domain.Artifact{
+		Name:     "profile.pstats",
+		MIMEType: "application/octet-stream",
+		Data:     data,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), profileSummaryTimeout)
+	defer cancel()
+	out, err := runQuiet(exec.CommandContext(ctx, pythonCommand(), "-c", pythonProfileSummary, path, strconv.Itoa(profileTopN)))
+	if err != nil {
+		profile.Warning = fmt.Sprintf("Error summarizing profile: %v", err)
+		return
+	}
+	var rows [][]string
+	if err := json.Unmarshal(out, &rows); err != nil {
+		profile.Warning = fmt.Sprintf("Error reading profile summary: %v", err)
+		return
+	}
+	profile.Tables = append(profile.Tables, domain.ProfileTable{
+		Title:   "Self time",
+		Columns: []string{"Calls", "Self (s)", "Cumulative (s)", "Function"},
+		Rows:    rows,
+	})
+}
+
+// runQuiet runs cmd and returns its stdout, folding stderr into the error
+func runQuiet(cmd *exec.Cmd) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
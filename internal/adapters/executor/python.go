@@ -3,21 +3,41 @@ package executor
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 
+	"github.com/aravi/code_execution_mcp/internal/config"
 	"github.com/aravi/code_execution_mcp/internal/core/domain"
 	"github.com/aravi/code_execution_mcp/internal/core/ports"
 )
 
 // PythonExecutor implements CodeExecutor for Python code
-type PythonExecutor struct{}
+type PythonExecutor struct {
+	// interpreter is the Python command every execution runs
+	interpreter string
+	pool        *PythonPool
+	policy      *pythonPolicy
+	sandbox     *Sandbox
+	observer    ports.Observer
+}
 
-// NewPythonExecutor creates a new Python executor
-func NewPythonExecutor() ports.CodeExecutor {
-	return &PythonExecutor{}
+// NewPythonExecutor creates a new Python executor that checks code against
+// policy before running it in sandbox, and reports its steps to observer
+func NewPythonExecutor(cfg config.PythonConfig, policy config.PythonPolicy, sandbox *Sandbox, observer ports.Observer) ports.CodeExecutor {
+	interpreter := pythonCommand()
+	e := &PythonExecutor{
+		interpreter: interpreter,
+		policy:      newPythonPolicy(interpreter, policy),
+		sandbox:     sandbox,
+		observer:    observerOrNop(observer),
+	}
+	if cfg.Pool.Enabled {
+		e.pool = NewPythonPool(interpreter, cfg.Pool)
+	}
+	return e
 }
 
 // Supports checks if this executor supports the given language
@@ -27,33 +47,101 @@ func (e *PythonExecutor) Supports(language string) bool {
 
 // Execute runs Python code
 func (e *PythonExecutor) Execute(ctx context.Context, req domain.ExecutionRequest) (*domain.ExecutionResult, error) {
+	prepared, rejected, err := e.prepare(ctx, req)
+	if prepared == nil {
+		return rejected, err
+	}
+	defer prepared.Cleanup()
+
+	// Hand the script to a warm interpreter when one is ready. The
+	// prepared command's first argument is the script path. Instrumented
+	// runs, confined runs, whose interpreter must start inside the
+	// confinement, and runs whose environment the sandbox changed, such as
+	// with a private HOME, always start a fresh interpreter.
+	if e.pool != nil && !req.Profile && !req.Coverage && prepared.Start == nil && prepared.Cmd.Env == nil {
+		if worker, ok := e.pool.take(); ok {
+			span := startStep(e.observer, prepared.Ctx, "process.run", req.Language)
+			span.SetAttributes(slog.Bool("python.worker_pool", true))
+			result, err := worker.run(prepared.Ctx, pythonJob{
+				Path: prepared.Cmd.Args[1],
+				Args: append([]string{}, req.Args...),
+				Cwd:  req.WorkingDir,
+			}, req.Stdin)
+			if result != nil {
+				result.WorkerPool = domain.CacheHit
+			}
+			endRun(e.observer, prepared.Ctx, span, req.Language, result)
+			return result, err
+		}
+	}
+
+	result, err := runPrepared(e.observer, prepared, req)
+	if result != nil && e.pool != nil {
+		result.WorkerPool = domain.CacheMiss
+	}
+	return result, err
+}
+
+// Prepare writes the script to a temporary file and builds the
+// interpreter process without starting it
+func (e *PythonExecutor) Prepare(ctx context.Context, req domain.ExecutionRequest) (ports.PreparedProcess, *domain.ExecutionResult, error) {
+	return exposePrepared(e.prepare(ctx, req))
+}
+
+// prepare writes the script and builds the interpreter process
+func (e *PythonExecutor) prepare(ctx context.Context, req domain.ExecutionRequest) (*PreparedCommand, *domain.ExecutionResult, error) {
 	if strings.TrimSpace(req.Code) == "" {
-		return &domain.ExecutionResult{
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
 			ErrorType: domain.ValidationError,
 			Stderr:    "Python code cannot be empty",
 		}, nil
 	}
 
-	timeout := getTimeout(req.Timeout, 30)
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	if req.Profile && req.Coverage {
+		return nil, &domain.ExecutionResult{
+			IsError:   true,
+			ErrorType: domain.ValidationError,
+			Stderr:    "Profiling and coverage cannot be combined; coverage tracing skews the profile",
+		}, nil
+	}
+
+	if e.policy != nil {
+		span := startStep(e.observer, ctx, "policy.check", req.Language)
+		violations, err := e.policy.Check(ctx, req.Code)
+		var rejected *domain.ExecutionResult
+		switch {
+		case err != nil:
+			rejected = &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("Error checking code against the policy: %v", err),
+			}
+		case len(violations) > 0:
+			rejected = policyRejection(req.Code, violations)
+		}
+		span.SetAttributes(slog.Int("policy.violations", len(violations)))
+		endStep(span, rejected)
+		if rejected != nil {
+			return nil, rejected, nil
+		}
+	}
 
 	// Create a temporary file for the Python script
 	tmpFile, err := os.CreateTemp("", "mcp_python_*.py")
 	if err != nil {
-		return &domain.ExecutionResult{
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
 			ErrorType: domain.SystemError,
 			Stderr:    fmt.Sprintf("Error creating temp file: %v", err),
 		}, nil
 	}
-	defer os.Remove(tmpFile.Name())
 
 	// Write the Python code to the temp file
 	if _, err := tmpFile.WriteString(req.Code); err != nil {
 		tmpFile.Close()
-		return &domain.ExecutionResult{
+		os.Remove(tmpFile.Name())
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
 			ErrorType: domain.SystemError,
 			Stderr:    fmt.Sprintf("Error writing to temp file: %v", err),
@@ -61,20 +149,121 @@ func (e *PythonExecutor) Execute(ctx context.Context, req domain.ExecutionReques
 	}
 	tmpFile.Close()
 
-	// Build command arguments
-	args := []string{tmpFile.Name()}
-	args = append(args, req.Args...)
+	timeout := getTimeout(req.Timeout, 30)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 
-	// Try python3 first, then python
-	pythonCmd := "python3"
-	if runtime.GOOS == "windows" {
-		pythonCmd = "python"
+	// Build command arguments. Instrumented runs go through a bootstrap
+	// that writes the profile or coverage data to dataPath.
+	args := []string{tmpFile.Name()}
+	dataPath := strings.TrimSuffix(tmpFile.Name(), ".py") + ".data"
+	switch {
+	case req.Profile:
+		args = []string{"-c", pythonInstrumentBootstrap, "profile", tmpFile.Name(), dataPath}
+	case req.Coverage:
+		args = []string{"-c", pythonInstrumentBootstrap, "coverage", tmpFile.Name(), dataPath}
 	}
+	args = append(args, req.Args...)
 
-	cmd := exec.CommandContext(ctx, pythonCmd, args...)
+	cmd := exec.CommandContext(ctx, e.interpreter, args...)
 	if req.WorkingDir != "" {
 		cmd.Dir = req.WorkingDir
 	}
 
-	return executeCommand(cmd)
+	prepared := &PreparedCommand{
+		Cmd:     cmd,
+		Ctx:     ctx,
+		Scratch: []string{tmpFile.Name()},
+		Cleanup: func() {
+			cancel()
+			os.Remove(tmpFile.Name())
+			os.Remove(dataPath)
+		},
+	}
+	switch {
+	case req.Profile:
+		prepared.Collect = func(result *domain.ExecutionResult) {
+			collectPythonProfile(dataPath, result)
+		}
+	case req.Coverage:
+		prepared.Collect = func(result *domain.ExecutionResult) {
+			collectPythonCoverage(dataPath, result)
+		}
+	}
+	if rejected := e.sandbox.Apply(prepared, req); rejected != nil {
+		prepared.Cleanup()
+		return nil, rejected, nil
+	}
+	return prepared, nil, nil
 }
+
+// pythonCommand returns the interpreter name for the host OS
+func pythonCommand() string {
+	if runtime.GOOS == "windows" {
+		return "python"
+	}
+	return "python3"
+}
+
+// pythonInstrumentBootstrap runs a script under cProfile ("profile") or
+// line coverage ("coverage") and writes the data even when the script
+// raises or calls sys.exit, whose status is kept. Coverage uses the
+// coverage package when installed and the stdlib trace module otherwise.
+// Tracebacks are trimmed to start at the script as in a plain run, and
+// pkgutil is imported up front so runpy's lazy import isn't measured.
+const pythonInstrumentBootstrap = `
+import json, os, pkgutil, runpy, sys, traceback
+
+_mode, _path, _out = sys.argv[1:4]
+sys.argv = [_path] + sys.argv[4:]
+sys.path[0] = os.path.dirname(_path)
+
+def _dump_lines(tool, statements, executed):
+    with open(_out, "w") as f:
+        json.dump({"tool": tool, "files": [{
+            "path": os.path.basename(_path),
+            "statements": sorted(statements),
+            "executed": sorted(executed),
+        }]}, f)
+
+if _mode == "profile":
+    import cProfile
+    _prof = cProfile.Profile()
+    _start = _prof.enable
+    def _finish():
+        _prof.disable()
+        _prof.dump_stats(_out)
+else:
+    try:
+        import coverage
+    except ImportError:
+        import trace
+        _tracer = trace.Trace(count=1, trace=0)
+        def _start():
+            sys.settrace(_tracer.globaltrace)
+        def _finish():
+            sys.settrace(None)
+            executed = [line for (name, line) in _tracer.counts if name == _path]
+            statements = [line for line in trace._find_executable_linenos(_path) if line > 0]
+            _dump_lines("trace", statements, executed)
+    else:
+        _cov = coverage.Coverage(data_file=None, include=[_path])
+        _start = _cov.start
+        def _finish():
+            _cov.stop()
+            _, statements, _, missing, _ = _cov.analysis2(_path)
+            _dump_lines("coverage.py", statements, set(statements) - set(missing))
+
+_start()
+try:
+    runpy.run_path(_path, run_name="__main__")
+except SystemExit:
+    raise
+except BaseException as exc:
+    tb = exc.__traceback__
+    while tb is not None and tb.tb_frame.f_code.co_filename != _path:
+        tb = tb.tb_next
+    traceback.print_exception(type(exc), exc, tb or exc.__traceback__)
+    sys.exit(1)
+finally:
+    _finish()
+`
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// pythonPolicyTimeout bounds the policy check of a single script
+const pythonPolicyTimeout = 10 * time.Second
+
+// pythonPolicyChecker reads a script on stdin and prints the policy
+// violations it contains as JSON. Names are resolved through the script's
+// import aliases, so `import os as o; o.system` counts as os.system.
+// Scripts with syntax errors pass, so that the interpreter reports them as
+// it would without a policy.
+const pythonPolicyChecker = `
+import ast, json, sys
+
+policy = json.loads(sys.argv[1])
+deny_imports = policy.get("deny_imports") or []
+allow_imports = policy.get("allow_imports") or []
+deny_calls = set(policy.get("deny_calls") or [])
+
+try:
+    tree = ast.parse(sys.stdin.read())
+except (SyntaxError, ValueError):
+    json.dump([], sys.stdout)
+    sys.exit(0)
+
+violations = []
+
+def report(node, rule, message):
+    violations.append({"rule": rule, "message": message, "line": node.lineno, "column": node.col_offset + 1})
+
+def matches(name, modules):
+    return any(name == m or name.startswith(m + ".") for m in modules)
+
+def check_import(node, module):
+    if matches(module, deny_imports):
+        report(node, "denied_import", "import of %s is not allowed" % module)
+    elif allow_imports and not matches(module, allow_imports):
+        report(node, "import_not_allowed", "%s is not in the allowed imports" % module)
+
+aliases = {}
+for node in ast.walk(tree):
+    if isinstance(node, ast.Import):
+        for alias in node.names:
+            check_import(node, alias.name)
+            if alias.asname:
+                aliases[alias.asname] = alias.name
+    elif isinstance(node, ast.ImportFrom) and node.level == 0 and node.module:
+        check_import(node, node.module)
+        for alias in node.names:
+            name = node.module + "." + alias.name
+            aliases[alias.asname or alias.name] = name
+            if name in deny_calls:
+                report(node, "denied_call", "import of %s is not allowed" % name)
+
+def resolve(node):
+    if isinstance(node, ast.Name):
+        return aliases.get(node.id, node.id)
+    if isinstance(node, ast.Attribute):
+        base = resolve(node.value)
+        return base and base + "." + node.attr
+    return None
+
+for node in ast.walk(tree):
+    if isinstance(node, (ast.Name, ast.Attribute)) and isinstance(node.ctx, ast.Load):
+        name = resolve(node)
+        if name and name.startswith("builtins."):
+            name = name[len("builtins."):]
+        if name in deny_calls:
+            report(node, "denied_call", "use of %s is not allowed" % name)
+
+violations.sort(key=lambda v: (v["line"], v["column"]))
+json.dump(violations, sys.stdout)
+`
+
+// pythonPolicy checks scripts against a config.PythonPolicy in a helper
+// interpreter
+type pythonPolicy struct {
+	python string
+	rules  string
+}
+
+// newPythonPolicy returns the checker for cfg, or nil when it is disabled
+func newPythonPolicy(python string, cfg config.PythonPolicy) *pythonPolicy {
+	if !cfg.Enabled {
+		return nil
+	}
+	rules, _ := json.Marshal(cfg)
+	return &pythonPolicy{python: python, rules: string(rules)}
+}
+
+// Check parses code and returns the violations it contains
+func (p *pythonPolicy) Check(ctx context.Context, code string) ([]domain.PolicyViolation, error) {
+	ctx, cancel := context.WithTimeout(ctx, pythonPolicyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.python, "-c", pythonPolicyChecker, p.rules)
+	cmd.Stdin = strings.NewReader(code)
+	out, err := runQuiet(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// The checker's lowercase keys match the fields case-insensitively
+	var violations []domain.PolicyViolation
+	if err := json.Unmarshal(out, &violations); err != nil {
+		return nil, fmt.Errorf("reading policy check output: %w", err)
+	}
+	return violations, nil
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// testPythonPolicy returns the checker for cfg, skipping the test when no
+// interpreter is installed to parse with
+func testPythonPolicy(t *testing.T, cfg config.PythonPolicy) *pythonPolicy {
+	t.Helper()
+	if _, err := exec.LookPath(pythonCommand()); err != nil {
+		t.Skipf("no %s to parse with: %v", pythonCommand(), err)
+	}
+	cfg.Enabled = true
+	return newPythonPolicy(pythonCommand(), cfg)
+}
+
+// checkPython returns the rules of the violations p finds in code. The
+// code is only parsed, never run.
+func checkPython(t *testing.T, p *pythonPolicy, code string) []string {
+	t.Helper()
+	violations, err := p.Check(context.Background(), code)
+	if err != nil {
+		t.Fatalf("Check(%q): %v", code, err)
+	}
+	var rules []string
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPythonPolicyImports(t *testing.T) {
+	deny := testPythonPolicy(t, config.PythonPolicy{DenyImports: []string{"subprocess", "ctypes", "os.path"}})
+	allow := testPythonPolicy(t, config.PythonPolicy{AllowImports: []string{"json", "math", "collections"}})
+	tests := []struct {
+		name   string
+		policy *pythonPolicy
+		code   string
+		rules  []string
+	}{
+		{"allowed", deny, "import json\nprint(json.dumps({}))", nil},
+		{"denied", deny, "import subprocess", []string{"denied_import"}},
+		{"denied submodule", deny, "import ctypes.util", []string{"denied_import"}},
+		{"denied from", deny, "from subprocess import run", []string{"denied_import"}},
+		{"denied alias", deny, "import subprocess as sp", []string{"denied_import"}},
+		{"several in one statement", deny, "import sys, subprocess, ctypes", []string{"denied_import", "denied_import"}},
+		{"denied inside a function", deny, "def f():\n    import subprocess\n", []string{"denied_import"}},
+		{"denied inside try", deny, "try:\n    import ctypes\nexcept ImportError:\n    pass\n", []string{"denied_import"}},
+		{"parent of a denied module", deny, "import os", nil},
+		{"denied dotted module", deny, "from os.path import join", []string{"denied_import"}},
+		{"prefix is not a parent", deny, "import subprocess2", nil},
+		{"relative import", deny, "from . import subprocess", nil},
+		{"name in a string", deny, "print('import subprocess')", nil},
+
+		{"in allow list", allow, "import json, math\nfrom collections import abc", nil},
+		{"below an allowed module", allow, "import collections.abc", nil},
+		{"not in allow list", allow, "import socket", []string{"import_not_allowed"}},
+		{"from not in allow list", allow, "from urllib.request import urlopen", []string{"import_not_allowed"}},
+	}
+	for _, tt := range tests {
+		if rules := checkPython(t, tt.policy, tt.code); !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("%s: Check(%q) = %q, want %q", tt.name, tt.code, rules, tt.rules)
+		}
+	}
+}
+
+func TestPythonPolicyCalls(t *testing.T) {
+	p := testPythonPolicy(t, config.PythonPolicy{DenyCalls: []string{"eval", "exec", "os.system", "builtins.open"}})
+	tests := []struct {
+		name  string
+		code  string
+		rules []string
+	}{
+		{"allowed", "import os\nprint(os.getcwd())", nil},
+		{"builtin", "eval('1 + 1')", []string{"denied_call"}},
+		{"attribute", "import os\nos.system('ls')", []string{"denied_call"}},
+		{"module alias", "import os as o\no.system('ls')", []string{"denied_call"}},
+		{"from import", "from os import system\nsystem('ls')", []string{"denied_call", "denied_call"}},
+		{"from import alias", "from os import system as run\nrun('ls')", []string{"denied_call", "denied_call"}},
+		{"reference without a call", "import os\nf = os.system", []string{"denied_call"}},
+		{"through builtins", "import builtins\nbuiltins.exec('x = 1')", []string{"denied_call"}},
+		{"nested", "def f():\n    return [eval(x) for x in '12']\n", []string{"denied_call"}},
+		{"assigned name", "eval = 1", nil},
+		{"method of the same name", "class C:\n    def eval(self): pass\nC().eval()", nil},
+		{"in a string", "print('os.system')", nil},
+	}
+	for _, tt := range tests {
+		if rules := checkPython(t, p, tt.code); !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("%s: Check(%q) = %q, want %q", tt.name, tt.code, rules, tt.rules)
+		}
+	}
+}
+
+func TestPythonPolicyPositionAndSyntax(t *testing.T) {
+	p := testPythonPolicy(t, config.PythonPolicy{DenyImports: []string{"subprocess"}, DenyCalls: []string{"eval"}})
+
+	violations, err := p.Check(context.Background(), "x = 1\nif x:\n    import subprocess\nprint(eval('2'))\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int{{3, 5}, {4, 7}}
+	if len(violations) != len(want) {
+		t.Fatalf("Check = %+v, want %d violations", violations, len(want))
+	}
+	for i, v := range violations {
+		if v.Line != want[i][0] || v.Column != want[i][1] {
+			t.Errorf("violation %d at %d:%d, want %d:%d", i, v.Line, v.Column, want[i][0], want[i][1])
+		}
+	}
+
+	// Syntax errors are left for the interpreter to report
+	if rules := checkPython(t, p, "import subprocess\ndef broken(:\n"); rules != nil {
+		t.Errorf("Check of invalid code = %q, want none", rules)
+	}
+}
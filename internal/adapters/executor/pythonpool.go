@@ -0,0 +1,276 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// pythonWorkerBootstrap runs inside each pooled interpreter. It imports the
+// preload modules, then blocks until a job header arrives on stdin and runs
+// the requested script as __main__. The header is read one byte at a time
+// so that anything after it stays unread for the script itself. Tracebacks
+// are trimmed to start at the script so they match a cold run.
+const pythonWorkerBootstrap = `
+import importlib, json, os, sys, traceback
+
+for _name in json.loads(sys.argv[1]):
+    try:
+        importlib.import_module(_name)
+    except Exception:
+        pass
+
+def _read_job():
+    buf = bytearray()
+    while True:
+        b = os.read(0, 1)
+        if not b:
+            sys.exit(0)
+        if b == b"\n":
+            return json.loads(buf)
+        buf += b
+
+_job = _read_job()
+if _job.get("cwd"):
+    os.chdir(_job["cwd"])
+sys.argv = [_job["path"]] + _job["args"]
+sys.path[0] = os.path.dirname(_job["path"])
+
+import runpy
+try:
+    runpy.run_path(_job["path"], run_name="__main__")
+except SystemExit:
+    raise
+except BaseException as exc:
+    tb = exc.__traceback__
+    while tb is not None and tb.tb_frame.f_code.co_filename != _job["path"]:
+        tb = tb.tb_next
+    traceback.print_exception(type(exc), exc, tb or exc.__traceback__)
+    sys.exit(1)
+`
+
+// pythonJob is the header sent to a pooled worker
+type pythonJob struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Cwd  string   `json:"cwd,omitempty"`
+}
+
+// pythonWorker is a pre-started interpreter waiting for a single job
+type pythonWorker struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  bytes.Buffer
+	stderr  bytes.Buffer
+	started time.Time
+	done    chan struct{}
+	waitErr error
+}
+
+// PythonPool keeps a number of warm interpreters ready so that executions
+// skip interpreter start-up and the import of preloaded modules. Workers
+// are single-use, so no state leaks between executions; the pool refills
+// in the background after each one is taken.
+type PythonPool struct {
+	python  string
+	preload string
+	recycle time.Duration
+	idle    chan *pythonWorker
+	wake    chan struct{}
+	stop    chan struct{}
+}
+
+// NewPythonPool creates a pool and starts filling it in the background
+func NewPythonPool(python string, cfg config.PoolConfig) *PythonPool {
+	size := cfg.Size
+	if size <= 0 {
+		size = 1
+	}
+	preload, _ := json.Marshal(append([]string{}, cfg.Preload...))
+
+	p := &PythonPool{
+		python:  python,
+		preload: string(preload),
+		recycle: time.Duration(cfg.RecycleAfterSeconds) * time.Second,
+		idle:    make(chan *pythonWorker, size),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	go p.maintain()
+	return p
+}
+
+// Close stops refilling the pool and terminates idle workers
+func (p *PythonPool) Close() {
+	close(p.stop)
+	for {
+		select {
+		case w := <-p.idle:
+			w.kill()
+		default:
+			return
+		}
+	}
+}
+
+// take returns a warm worker, or false when none is ready
+func (p *PythonPool) take() (*pythonWorker, bool) {
+	defer p.refill()
+	for {
+		select {
+		case w := <-p.idle:
+			if p.stale(w) || w.exited() {
+				w.kill()
+				continue
+			}
+			return w, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// refill asks the maintenance loop to top up the pool
+func (p *PythonPool) refill() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// maintain keeps the pool full and replaces workers that idled too long
+func (p *PythonPool) maintain() {
+	interval := time.Minute
+	if p.recycle > 0 && p.recycle < interval {
+		interval = p.recycle
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.recycleStale()
+		for len(p.idle) < cap(p.idle) {
+			w, err := p.spawn()
+			if err != nil {
+				// Wait for the next tick rather than spinning on a broken interpreter
+				break
+			}
+			select {
+			case p.idle <- w:
+			case <-p.stop:
+				w.kill()
+				return
+			}
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// recycleStale replaces idle workers that exceeded the recycle age
+func (p *PythonPool) recycleStale() {
+	for i := len(p.idle); i > 0; i-- {
+		select {
+		case w := <-p.idle:
+			if p.stale(w) || w.exited() {
+				w.kill()
+				continue
+			}
+			p.idle <- w
+		default:
+			return
+		}
+	}
+}
+
+// stale reports whether a worker has been idle past the recycle age
+func (p *PythonPool) stale(w *pythonWorker) bool {
+	return p.recycle > 0 && time.Since(w.started) > p.recycle
+}
+
+// spawn starts a new worker
+func (p *PythonPool) spawn() (*pythonWorker, error) {
+	w := &pythonWorker{
+		cmd:  exec.Command(p.python, "-c", pythonWorkerBootstrap, p.preload),
+		done: make(chan struct{}),
+	}
+	w.cmd.Stdout = &w.stdout
+	w.cmd.Stderr = &w.stderr
+
+	stdin, err := w.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	w.stdin = stdin
+
+	if err := w.cmd.Start(); err != nil {
+		return nil, err
+	}
+	w.started = time.Now()
+
+	go func() {
+		w.waitErr = w.cmd.Wait()
+		close(w.done)
+	}()
+	return w, nil
+}
+
+// run hands a job and its stdin to the worker and waits for it to finish
+// or for ctx to be cancelled, in which case the worker is killed
+func (w *pythonWorker) run(ctx context.Context, job pythonJob, stdin string) (*domain.ExecutionResult, error) {
+	header, err := json.Marshal(job)
+	if err != nil {
+		w.kill()
+		return nil, fmt.Errorf("encoding job: %w", err)
+	}
+
+	startTime := time.Now()
+	go func() {
+		w.stdin.Write(append(header, '\n'))
+		io.WriteString(w.stdin, stdin)
+		w.stdin.Close()
+	}()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.kill()
+	}
+	duration := time.Since(startTime)
+
+	result := newResult(w.waitErr, w.stdout.String(), w.stderr.String(), duration)
+	recordUsage(result, w.cmd.ProcessState)
+	markTimeout(ctx, result)
+	markOutOfMemory(ctx, result)
+	return result, nil
+}
+
+// exited reports whether the worker process has already terminated
+func (w *pythonWorker) exited() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// kill terminates the worker and waits for it to exit
+func (w *pythonWorker) kill() {
+	if w.cmd.Process != nil {
+		w.cmd.Process.Kill()
+	}
+	<-w.done
+}
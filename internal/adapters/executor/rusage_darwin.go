@@ -0,0 +1,23 @@
+package executor
+
+import (
+	"os"
+	"syscall"
+)
+
+// peakMemoryKB returns the peak resident set size of an exited process.
+// macOS reports ru_maxrss in bytes.
+func peakMemoryKB(state *os.ProcessState) int64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return usage.Maxrss / 1024
+	}
+	return 0
+}
+
+// exitSignal names the signal that killed an exited process, if any
+func exitSignal(state *os.ProcessState) string {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return status.Signal().String()
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package executor
+
+import "os"
+
+// peakMemoryKB is not available on this platform
+func peakMemoryKB(state *os.ProcessState) int64 {
+	return 0
+}
+
+// exitSignal is not available on this platform
+func exitSignal(state *os.ProcessState) string {
+	return ""
+}
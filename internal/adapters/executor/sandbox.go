@@ -0,0 +1,470 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// networkStrictness orders the network modes, strictest first
+var networkStrictness = map[string]int{
+	domain.NetworkNone:      0,
+	domain.NetworkLoopback:  1,
+	domain.NetworkAllowlist: 2,
+	domain.NetworkHost:      3,
+}
+
+// proxyEnv lists the variables that point clients at a proxy
+var proxyEnv = []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "NO_PROXY"}
+
+// Sandbox confines prepared processes. A nil Sandbox leaves them alone.
+type Sandbox struct {
+	network    config.NetworkConfig
+	filesystem config.FilesystemConfig
+	seccompCfg config.SeccompConfig
+	user       config.UserConfig
+	umask      int
+
+	// uids is set when each client session runs as its own user
+	uids *uidPool
+
+	// seccomp is the compiled filter, or nil when filtering is disabled or
+	// unavailable
+	seccomp *compiledSeccomp
+
+	// seccompStarts serializes starts under the filter. Go forks with a
+	// raw clone that keeps its scheduler slot until the child has called
+	// execve, and the filter refers both calls to the server, so a slot
+	// must remain free to answer them.
+	seccompStarts sync.Mutex
+
+	// What the host supports, probed once when the sandbox is created
+	landlockABI int
+	landlockErr error
+	netnsErr    error
+	seccompErr  error
+	cgroups     string
+	cgroupsErr  error
+}
+
+// confinement restricts the calling OS thread before cmd is started from
+// it, so that the child inherits the restriction
+type confinement func(cmd *exec.Cmd) error
+
+// NewSandbox creates a sandbox from cfg and probes the host for the
+// features it relies on. It fails when the seccomp profile cannot be
+// loaded, and when the server runs as root but executions would too.
+func NewSandbox(cfg config.SandboxConfig) (*Sandbox, error) {
+	if os.Geteuid() == 0 && cfg.User.UID == 0 && cfg.User.UIDPool.Size == 0 && !cfg.User.AllowRoot {
+		return nil, errors.New("refusing to run as root, since executed code would run as root too; set sandbox.user.uid or sandbox.user.uid_pool, or set sandbox.user.allow_root")
+	}
+	umask, err := cfg.User.ParseUmask()
+	if err != nil {
+		return nil, err
+	}
+	s := probeSandbox(cfg)
+	s.umask = umask
+
+	profile, err := loadSeccompProfile(cfg.Seccomp.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil && s.seccompErr == nil {
+		if s.seccomp, err = compileSeccomp(profile); err != nil {
+			return nil, err
+		}
+		if runtime.GOMAXPROCS(0) < 2 {
+			runtime.GOMAXPROCS(2)
+		}
+	}
+	return s, nil
+}
+
+// ProbeCapabilities reports the confinement features of the host, and
+// whether cfg uses them, without checking that cfg is valid, so that they
+// can be reported when NewSandbox rejects cfg
+func ProbeCapabilities(cfg config.SandboxConfig) []domain.Capability {
+	return probeSandbox(cfg).Capabilities()
+}
+
+// probeSandbox probes the host for the features cfg may use
+func probeSandbox(cfg config.SandboxConfig) *Sandbox {
+	s := &Sandbox{network: cfg.Network, filesystem: cfg.Filesystem, seccompCfg: cfg.Seccomp, user: cfg.User}
+	if cfg.User.UIDPool.Size > 0 {
+		s.uids = newUIDPool(cfg.User.UIDPool.Start, cfg.User.UIDPool.Size)
+	}
+	s.landlockABI, s.landlockErr = landlockABI()
+	s.netnsErr = probeNetworkNamespace()
+	s.seccompErr = probeSeccomp()
+	s.cgroups, s.cgroupsErr = probeCgroups()
+	return s
+}
+
+// seccompEnabled reports whether a seccomp profile is configured
+func (s *Sandbox) seccompEnabled() bool {
+	return s.seccompCfg.Profile != seccompProfileNone
+}
+
+// probeCgroups describes the cgroup hierarchy mounted at /sys/fs/cgroup
+func probeCgroups() (string, error) {
+	controllers, err := os.ReadFile("/sys/fs/cgroup/cgroup.controllers")
+	if err == nil {
+		return "cgroup v2, controllers " + strings.Join(strings.Fields(string(controllers)), " "), nil
+	}
+	if info, err := os.Stat("/sys/fs/cgroup/memory"); err == nil && info.IsDir() {
+		return "cgroup v1", nil
+	}
+	return "", errors.New("no cgroup hierarchy at /sys/fs/cgroup")
+}
+
+// Capabilities reports Landlock, network namespace, seccomp and user
+// switching support, and the cgroups available to a supervisor
+func (s *Sandbox) Capabilities() []domain.Capability {
+	landlock := domain.Capability{
+		Name:      "landlock",
+		Available: s.landlockErr == nil,
+		Enabled:   s.filesystem.Backend == config.FilesystemLandlock,
+		Detail:    fmt.Sprintf("ABI version %d", s.landlockABI),
+	}
+	if s.landlockErr != nil {
+		landlock.Detail = s.landlockErr.Error()
+	}
+
+	netns := domain.Capability{
+		Name:      "network_namespace",
+		Available: s.netnsErr == nil,
+		Enabled:   s.network.DefaultMode != domain.NetworkHost,
+		Detail:    "default mode " + s.network.DefaultMode,
+	}
+	if s.netnsErr != nil {
+		netns.Detail = s.netnsErr.Error()
+	}
+
+	seccomp := domain.Capability{
+		Name:      "seccomp",
+		Available: s.seccompErr == nil,
+		Enabled:   s.seccompEnabled(),
+		Detail:    "profile " + s.seccompCfg.Profile,
+	}
+	if s.seccompErr != nil {
+		seccomp.Detail = s.seccompErr.Error()
+	}
+	user := domain.Capability{
+		Name:      "run_as_user",
+		Available: runtime.GOOS == "linux" && os.Geteuid() == 0,
+		Detail:    fmt.Sprintf("runs as the server's user (uid %d)", os.Geteuid()),
+	}
+	switch {
+	case s.uids != nil:
+		user.Enabled = true
+		user.Detail = fmt.Sprintf("uid pool %d-%d", s.uids.start, s.uids.start+s.uids.size-1)
+	case s.user.UID != 0:
+		user.Enabled = true
+		user.Detail = fmt.Sprintf("uid %d", s.user.UID)
+	}
+
+	// Executions are not placed in cgroups; this tells operators whether a
+	// supervisor could
+	cgroups := domain.Capability{
+		Name:      "cgroups",
+		Available: s.cgroupsErr == nil,
+		Detail:    s.cgroups,
+	}
+	if s.cgroupsErr != nil {
+		cgroups.Detail = s.cgroupsErr.Error()
+	}
+	return []domain.Capability{landlock, netns, seccomp, user, cgroups}
+}
+
+// Apply sets up the confinement req asks for, and the filesystem and
+// system call confinement the server is configured with, on prepared. It
+// returns a rejection when the network mode is unknown or looser than the
+// server's default, or when a configured feature is unavailable.
+func (s *Sandbox) Apply(prepared *PreparedCommand, req domain.ExecutionRequest) *domain.ExecutionResult {
+	if s == nil {
+		return nil
+	}
+
+	steps, rejected := s.networkSteps(prepared, req)
+	if rejected != nil {
+		return rejected
+	}
+	userSteps, rejected := s.userSteps(prepared, req)
+	if rejected != nil {
+		return rejected
+	}
+	steps = append(steps, userSteps...)
+
+	if s.filesystem.Backend == config.FilesystemLandlock {
+		if s.landlockErr != nil {
+			return &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("The landlock filesystem sandbox is configured but unavailable: %v", s.landlockErr),
+			}
+		}
+		steps = append(steps, s.landlockStep(prepared.Cmd))
+	}
+
+	// The filter goes last, since it may deny the calls the other steps
+	// make
+	if s.seccompEnabled() {
+		if s.seccompErr != nil {
+			return &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("The seccomp profile %s is configured but unavailable: %v", s.seccompCfg.Profile, s.seccompErr),
+			}
+		}
+		steps = append(steps, s.seccompStep(prepared))
+	}
+
+	if len(steps) > 0 {
+		cmd := prepared.Cmd
+		prepared.Start = func() error {
+			if s.seccomp != nil {
+				s.seccompStarts.Lock()
+				defer s.seccompStarts.Unlock()
+			}
+			return startConfined(cmd, steps)
+		}
+	}
+	return nil
+}
+
+// networkSteps returns the confinement for the network mode of req
+func (s *Sandbox) networkSteps(prepared *PreparedCommand, req domain.ExecutionRequest) ([]confinement, *domain.ExecutionResult) {
+	mode := req.Network
+	if mode == "" {
+		mode = s.network.DefaultMode
+	}
+	strictness, ok := networkStrictness[mode]
+	if !ok {
+		return nil, &domain.ExecutionResult{
+			IsError:   true,
+			ErrorType: domain.ValidationError,
+			Stderr:    fmt.Sprintf("Unknown network mode %q (use none, loopback, allowlist or host)", mode),
+		}
+	}
+	if strictness > networkStrictness[s.network.DefaultMode] {
+		return nil, &domain.ExecutionResult{
+			IsError:   true,
+			ErrorType: domain.ValidationError,
+			Stderr:    fmt.Sprintf("Network mode %q is looser than the server's default (%s)", mode, s.network.DefaultMode),
+		}
+	}
+	if mode == domain.NetworkHost {
+		return nil, nil
+	}
+
+	var proxy *egressProxy
+	if mode == domain.NetworkAllowlist {
+		proxy = newEgressProxy(s.network.Allowlist)
+
+		collect, cleanup := prepared.Collect, prepared.Cleanup
+		prepared.Collect = func(result *domain.ExecutionResult) {
+			if collect != nil {
+				collect(result)
+			}
+			result.Connections = proxy.Connections()
+		}
+		prepared.Cleanup = func() {
+			proxy.Close()
+			cleanup()
+		}
+	}
+	return []confinement{networkNamespace(mode != domain.NetworkNone, proxy)}, nil
+}
+
+// switchesUser reports whether processes run as a user other than the
+// server's
+func (s *Sandbox) switchesUser() bool {
+	return s != nil && (s.uids != nil || s.user.UID != 0)
+}
+
+// ReleaseSession returns the UID a closed session held to the pool, once
+// the processes still running as it are killed and the files it owns in
+// the temporary directory and the writable paths are deleted. When that
+// fails the UID stays held, so that no other session inherits what is
+// left.
+func (s *Sandbox) ReleaseSession(session string) error {
+	if s == nil || s.uids == nil {
+		return nil
+	}
+	uid, ok := s.uids.lookup(session)
+	if !ok {
+		return nil
+	}
+	if err := killUser(uid); err != nil {
+		return fmt.Errorf("killing the processes of uid %d: %w", uid, err)
+	}
+	dirs := append([]string{os.TempDir()}, s.filesystem.ReadWrite...)
+	for _, dir := range dirs {
+		if err := removeOwned(dir, uid); err != nil {
+			return fmt.Errorf("deleting the files of uid %d: %w", uid, err)
+		}
+	}
+	s.uids.release(session)
+	return nil
+}
+
+// userSteps runs the process as the configured user, or the user of the
+// request's session, with a private home directory and umask if they are
+// configured. The executor's scratch files are handed over to the user.
+func (s *Sandbox) userSteps(prepared *PreparedCommand, req domain.ExecutionRequest) ([]confinement, *domain.ExecutionResult) {
+	uid, gid := s.user.UID, s.user.GID
+	if s.uids != nil {
+		var ok bool
+		if uid, ok = s.uids.acquire(req.Session); !ok {
+			return nil, &domain.ExecutionResult{
+				IsError:   true,
+				ErrorType: domain.SystemError,
+				Stderr:    fmt.Sprintf("No user is free for this session: all %d UIDs in the pool are held by other sessions", s.uids.size),
+			}
+		}
+	}
+	if gid == 0 {
+		gid = uid
+	}
+	failed := func(format string, err error) *domain.ExecutionResult {
+		return &domain.ExecutionResult{
+			IsError:   true,
+			ErrorType: domain.SystemError,
+			Stderr:    fmt.Sprintf(format, err),
+		}
+	}
+
+	if uid != 0 {
+		for _, path := range prepared.Scratch {
+			if err := chownTree(path, uid, gid); err != nil {
+				return nil, failed("Error handing temporary files to the execution user: %v", err)
+			}
+		}
+	}
+
+	if s.user.PrivateHome {
+		home, err := os.MkdirTemp("", "mcp_home_*")
+		if err != nil {
+			return nil, failed("Error creating home directory: %v", err)
+		}
+		cleanup := prepared.Cleanup
+		prepared.Cleanup = func() {
+			os.RemoveAll(home)
+			cleanup()
+		}
+		if uid != 0 {
+			if err := os.Lchown(home, uid, gid); err != nil {
+				return nil, failed("Error handing the home directory to the execution user: %v", err)
+			}
+		}
+
+		env := prepared.Cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		prepared.Cmd.Env = withEnv(env, "HOME", home)
+	}
+
+	if uid == 0 && s.umask < 0 {
+		return nil, nil
+	}
+	return []confinement{runAs(uid, gid, s.umask)}, nil
+}
+
+// landlockStep returns the Landlock confinement for cmd. Besides the
+// configured paths, the program's own directory is readable and the
+// working directory and temp directory are writable.
+func (s *Sandbox) landlockStep(cmd *exec.Cmd) confinement {
+	readOnly := append([]string{}, s.filesystem.ReadOnly...)
+	if filepath.IsAbs(cmd.Path) {
+		readOnly = append(readOnly, filepath.Dir(cmd.Path))
+	}
+	readWrite := append([]string{os.TempDir()}, s.filesystem.ReadWrite...)
+	if cmd.Dir != "" {
+		readWrite = append(readWrite, cmd.Dir)
+	}
+
+	abi := s.landlockABI
+	return func(*exec.Cmd) error {
+		return restrictFilesystem(abi, readOnly, readWrite)
+	}
+}
+
+// seccompStep returns the step that installs the seccomp filter. The
+// calls it denied are reported with the result; when the process failed
+// after a denial, the failure is attributed to the filter.
+func (s *Sandbox) seccompStep(prepared *PreparedCommand) confinement {
+	supervisor := newSeccompSupervisor(s.seccomp)
+
+	collect, cleanup := prepared.Collect, prepared.Cleanup
+	prepared.Collect = func(result *domain.ExecutionResult) {
+		if collect != nil {
+			collect(result)
+		}
+		result.BlockedSyscalls = supervisor.Blocked()
+		if len(result.BlockedSyscalls) > 0 && result.ErrorType == domain.RuntimeError {
+			result.ErrorType = domain.SyscallBlockedError
+			if result.Stderr != "" && !strings.HasSuffix(result.Stderr, "\n") {
+				result.Stderr += "\n"
+			}
+			result.Stderr += "Blocked system calls: " + strings.Join(result.BlockedSyscalls, ", ")
+		}
+	}
+	prepared.Cleanup = func() {
+		supervisor.Close()
+		cleanup()
+	}
+	return supervisor.install
+}
+
+// startConfined starts cmd from an OS thread restricted by steps, in
+// order. The thread is locked for the purpose and never unlocked, so the
+// runtime discards it, and its restrictions with it, once the child has
+// been started.
+func startConfined(cmd *exec.Cmd, steps []confinement) error {
+	done := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		for _, step := range steps {
+			if err := step(cmd); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- cmd.Start()
+	}()
+	return <-done
+}
+
+// withProxyEnv returns env with the proxy variables pointing at address,
+// in both the upper and lower case spellings clients look for
+func withProxyEnv(env []string, address string) []string {
+	var kept []string
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		proxyVar := false
+		for _, v := range proxyEnv {
+			if strings.EqualFold(name, v) {
+				proxyVar = true
+				break
+			}
+		}
+		if !proxyVar {
+			kept = append(kept, entry)
+		}
+	}
+
+	url := "http://" + address
+	for _, v := range proxyEnv[:3] {
+		kept = append(kept, v+"="+url, strings.ToLower(v)+"="+url)
+	}
+	return kept
+}
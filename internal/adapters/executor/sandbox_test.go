@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithProxyEnv(t *testing.T) {
+	const address = "127.0.0.1:3128"
+	proxyVars := []string{
+		"HTTP_PROXY=http://" + address, "http_proxy=http://" + address,
+		"HTTPS_PROXY=http://" + address, "https_proxy=http://" + address,
+		"ALL_PROXY=http://" + address, "all_proxy=http://" + address,
+	}
+
+	tests := []struct {
+		name string
+		env  []string
+		want []string
+	}{
+		{
+			name: "empty",
+			want: proxyVars,
+		},
+		{
+			name: "other variables are kept in order",
+			env:  []string{"PATH=/usr/bin", "HOME=/home/u"},
+			want: append([]string{"PATH=/usr/bin", "HOME=/home/u"}, proxyVars...),
+		},
+		{
+			name: "inherited proxies are replaced in either case",
+			env:  []string{"HTTP_PROXY=http://corp:8080", "https_proxy=http://corp:8080", "Http_Proxy=x", "PATH=/usr/bin"},
+			want: append([]string{"PATH=/usr/bin"}, proxyVars...),
+		},
+		{
+			name: "no_proxy is dropped so nothing bypasses the proxy",
+			env:  []string{"NO_PROXY=localhost,internal", "no_proxy=*"},
+			want: proxyVars,
+		},
+		{
+			name: "similar names are kept",
+			env:  []string{"HTTP_PROXY_USER=u", "MY_ALL_PROXY=x"},
+			want: append([]string{"HTTP_PROXY_USER=u", "MY_ALL_PROXY=x"}, proxyVars...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withProxyEnv(slices.Clone(tt.env), address)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("withProxyEnv(%q) =\n%q\nwant\n%q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
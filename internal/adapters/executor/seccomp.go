@@ -0,0 +1,318 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+)
+
+// Built-in seccomp profiles
+const (
+	seccompProfileNone        = "none"
+	seccompProfileDefault     = "default"
+	seccompProfileStrict      = "strict"
+	seccompProfileComputeOnly = "compute-only"
+)
+
+// Errno values used by the built-in profiles, which are the same on every
+// Linux architecture
+const (
+	errnoEPERM  = 1
+	errnoENOSYS = 38
+)
+
+// Constants for the argument checks of the built-in profiles
+const (
+	afUnix       = 1
+	afPacket     = 17
+	sockRaw      = 3
+	sockTypeMask = 0xf
+	cloneThread  = 0x10000
+)
+
+// seccompActionKind is what a filter does with a matching system call
+type seccompActionKind int
+
+const (
+	// seccompAllow lets the call through
+	seccompAllow seccompActionKind = iota
+
+	// seccompLog lets the call through and logs it to the audit log
+	seccompLog
+
+	// seccompErrno fails the call with errno without telling the server,
+	// for calls such as clone3 that callers retry another way
+	seccompErrno
+
+	// seccompDeny fails the call with errno and records it
+	seccompDeny
+
+	// seccompKill records the call and kills the process
+	seccompKill
+
+	// seccompSpawn allows only the calls that start the program: the
+	// server's clone and the child's first execve. Later calls are denied.
+	seccompSpawn
+)
+
+// seccompAction is a filter verdict
+type seccompAction struct {
+	kind  seccompActionKind
+	errno int
+}
+
+// seccompArg is a condition on a system call argument, in the OCI format
+type seccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+// seccompRule applies action to calls of names whose arguments meet every
+// condition in args
+type seccompRule struct {
+	names  []string
+	args   []seccompArg
+	action seccompAction
+}
+
+// seccompProfile is a system call filter. The first matching rule decides
+// a call; calls no rule matches get defaultAction.
+type seccompProfile struct {
+	name          string
+	defaultAction seccompAction
+	rules         []seccompRule
+}
+
+var (
+	denyEPERM    = seccompAction{kind: seccompDeny, errno: errnoEPERM}
+	spawnOnly    = seccompAction{kind: seccompSpawn, errno: errnoEPERM}
+	silentENOSYS = seccompAction{kind: seccompErrno, errno: errnoENOSYS}
+
+	// seccompAdminCalls administer the machine rather than compute
+	seccompAdminCalls = []string{
+		"acct", "clock_adjtime", "clock_settime", "create_module", "delete_module",
+		"finit_module", "fsmount", "fsopen", "fspick", "init_module", "ioperm", "iopl",
+		"kexec_file_load", "kexec_load", "lookup_dcookie", "mount", "mount_setattr",
+		"move_mount", "nfsservctl", "open_by_handle_at", "open_tree", "pivot_root",
+		"quotactl", "quotactl_fd", "reboot", "setdomainname", "sethostname",
+		"settimeofday", "swapoff", "swapon", "umount2", "vhangup",
+	}
+
+	// seccompIntrospectionCalls reach into other processes or the kernel
+	seccompIntrospectionCalls = []string{
+		"add_key", "bpf", "io_uring_enter", "io_uring_register", "io_uring_setup",
+		"kcmp", "keyctl", "perf_event_open", "personality", "process_vm_readv",
+		"process_vm_writev", "ptrace", "request_key", "setns", "unshare", "userfaultfd",
+	}
+
+	// seccompRawSocketRules deny packet sockets and raw IP sockets
+	seccompRawSocketRules = []seccompRule{
+		{names: []string{"socket"}, args: []seccompArg{{Index: 0, Value: afPacket, Op: "SCMP_CMP_EQ"}}, action: denyEPERM},
+		{names: []string{"socket"}, args: []seccompArg{{Index: 1, Value: sockTypeMask, ValueTwo: sockRaw, Op: "SCMP_CMP_MASKED_EQ"}}, action: denyEPERM},
+	}
+)
+
+// builtinSeccompProfile returns the built-in profile called name
+func builtinSeccompProfile(name string) (*seccompProfile, bool) {
+	allow := seccompAction{kind: seccompAllow}
+	defaultRules := []seccompRule{{names: seccompAdminCalls, action: denyEPERM}}
+	strictRules := append(append(slices.Clone(defaultRules),
+		seccompRule{names: seccompIntrospectionCalls, action: denyEPERM}),
+		seccompRawSocketRules...)
+
+	switch name {
+	case seccompProfileDefault:
+		return &seccompProfile{name: name, defaultAction: allow, rules: defaultRules}, true
+	case seccompProfileStrict:
+		return &seccompProfile{name: name, defaultAction: allow, rules: strictRules}, true
+	case seccompProfileComputeOnly:
+		rules := append(strictRules,
+			// Sockets other than Unix sockets reach the network
+			seccompRule{names: []string{"socket"}, args: []seccompArg{{Index: 0, Value: afUnix, Op: "SCMP_CMP_NE"}}, action: denyEPERM},
+			// clone3 can't be filtered by flags; libc falls back to clone
+			seccompRule{names: []string{"clone3"}, action: silentENOSYS},
+			// Threads are fine, new processes and programs are not
+			seccompRule{names: []string{"clone"}, args: []seccompArg{{Index: 0, Value: cloneThread, ValueTwo: 0, Op: "SCMP_CMP_MASKED_EQ"}}, action: spawnOnly},
+			seccompRule{names: []string{"fork", "vfork", "execve", "execveat"}, action: spawnOnly},
+		)
+		return &seccompProfile{name: name, defaultAction: allow, rules: rules}, true
+	}
+	return nil, false
+}
+
+// loadSeccompProfile returns the built-in profile called name, or parses
+// name as the path of a profile in the OCI (Docker) seccomp format. It
+// returns nil for the "none" profile.
+func loadSeccompProfile(name string) (*seccompProfile, error) {
+	if name == "" || name == seccompProfileNone {
+		return nil, nil
+	}
+	if profile, ok := builtinSeccompProfile(name); ok {
+		return profile, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading seccomp profile: %w", err)
+	}
+	profile, err := parseOCISeccomp(data)
+	if err != nil {
+		return nil, fmt.Errorf("seccomp profile %s: %w", name, err)
+	}
+	profile.name = name
+	return profile, nil
+}
+
+// ociSeccomp is the subset of the OCI runtime spec's seccomp object that
+// the sandbox understands
+type ociSeccomp struct {
+	DefaultAction   string `json:"defaultAction"`
+	DefaultErrnoRet *int   `json:"defaultErrnoRet"`
+	Syscalls        []struct {
+		Names    []string     `json:"names"`
+		Name     string       `json:"name"`
+		Action   string       `json:"action"`
+		ErrnoRet *int         `json:"errnoRet"`
+		Args     []seccompArg `json:"args"`
+		Includes ociCondition `json:"includes"`
+		Excludes ociCondition `json:"excludes"`
+	} `json:"syscalls"`
+}
+
+// ociCondition limits a rule to some architectures or capabilities
+type ociCondition struct {
+	Arches []string `json:"arches"`
+	Caps   []string `json:"caps"`
+}
+
+// ociArches holds the names a profile may use for the host architecture
+var ociArches = map[string][]string{
+	"amd64": {"amd64", "x86_64", "SCMP_ARCH_X86_64"},
+	"arm64": {"arm64", "aarch64", "SCMP_ARCH_AARCH64"},
+}
+
+// parseOCISeccomp converts an OCI seccomp profile. Rules limited to other
+// architectures are dropped, as are rules that need capabilities, since
+// executed code is not meant to use them. minKernel conditions are not
+// checked.
+func parseOCISeccomp(data []byte) (*seccompProfile, error) {
+	var spec ociSeccomp
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	defaultErrno := errnoEPERM
+	if spec.DefaultErrnoRet != nil {
+		defaultErrno = *spec.DefaultErrnoRet
+	}
+	defaultAction, err := parseOCIAction(spec.DefaultAction, defaultErrno)
+	if err != nil {
+		return nil, fmt.Errorf("defaultAction: %w", err)
+	}
+
+	profile := &seccompProfile{defaultAction: defaultAction}
+	arch := ociArches[runtime.GOARCH]
+	for i, sc := range spec.Syscalls {
+		if len(sc.Includes.Caps) > 0 {
+			continue
+		}
+		if len(sc.Includes.Arches) > 0 && !overlaps(sc.Includes.Arches, arch) {
+			continue
+		}
+		if overlaps(sc.Excludes.Arches, arch) {
+			continue
+		}
+
+		errno := defaultErrno
+		if sc.ErrnoRet != nil {
+			errno = *sc.ErrnoRet
+		}
+		action, err := parseOCIAction(sc.Action, errno)
+		if err != nil {
+			return nil, fmt.Errorf("syscalls[%d]: %w", i, err)
+		}
+		for _, arg := range sc.Args {
+			if err := arg.validate(); err != nil {
+				return nil, fmt.Errorf("syscalls[%d]: %w", i, err)
+			}
+		}
+
+		names := sc.Names
+		if sc.Name != "" {
+			names = append(names, sc.Name)
+		}
+		profile.rules = append(profile.rules, seccompRule{names: names, args: sc.Args, action: action})
+	}
+	return profile, nil
+}
+
+// parseOCIAction converts an OCI action name. Denials with ENOSYS are not
+// recorded, since callers treat them as a missing feature and fall back.
+func parseOCIAction(name string, errno int) (seccompAction, error) {
+	switch name {
+	case "SCMP_ACT_ALLOW":
+		return seccompAction{kind: seccompAllow}, nil
+	case "SCMP_ACT_LOG":
+		return seccompAction{kind: seccompLog}, nil
+	case "SCMP_ACT_ERRNO":
+		if errno == errnoENOSYS {
+			return seccompAction{kind: seccompErrno, errno: errno}, nil
+		}
+		return seccompAction{kind: seccompDeny, errno: errno}, nil
+	case "SCMP_ACT_TRACE", "SCMP_ACT_NOTIFY":
+		return denyEPERM, nil
+	case "SCMP_ACT_KILL", "SCMP_ACT_KILL_THREAD", "SCMP_ACT_KILL_PROCESS", "SCMP_ACT_TRAP":
+		return seccompAction{kind: seccompKill, errno: errnoEPERM}, nil
+	default:
+		return seccompAction{}, fmt.Errorf("unsupported action %q", name)
+	}
+}
+
+// validate checks the operator and index of an argument condition
+func (a seccompArg) validate() error {
+	if a.Index > 5 {
+		return fmt.Errorf("argument index %d out of range", a.Index)
+	}
+	switch a.Op {
+	case "SCMP_CMP_EQ", "SCMP_CMP_NE", "SCMP_CMP_LT", "SCMP_CMP_LE", "SCMP_CMP_GT", "SCMP_CMP_GE", "SCMP_CMP_MASKED_EQ":
+		return nil
+	default:
+		return fmt.Errorf("unsupported argument operator %q", a.Op)
+	}
+}
+
+// matches reports whether an argument value meets the condition
+func (a seccompArg) matches(value uint64) bool {
+	switch a.Op {
+	case "SCMP_CMP_EQ":
+		return value == a.Value
+	case "SCMP_CMP_NE":
+		return value != a.Value
+	case "SCMP_CMP_LT":
+		return value < a.Value
+	case "SCMP_CMP_LE":
+		return value <= a.Value
+	case "SCMP_CMP_GT":
+		return value > a.Value
+	case "SCMP_CMP_GE":
+		return value >= a.Value
+	case "SCMP_CMP_MASKED_EQ":
+		return value&a.Value == a.ValueTwo
+	default:
+		return false
+	}
+}
+
+// overlaps reports whether a and b share an element
+func overlaps(a, b []string) bool {
+	for _, s := range a {
+		if slices.Contains(b, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,422 @@
+//go:build linux && (amd64 || arm64)
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Kernel constants for seccomp filters and user notifications
+const (
+	seccompSetModeFilter     = 1
+	seccompGetActionAvail    = 2
+	seccompFlagNewListener   = 1 << 3
+	seccompUserNotifContinue = 1 << 0
+
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetUserNotif   = 0x7fc00000
+	seccompRetLog         = 0x7ffc0000
+	seccompRetAllow       = 0x7fff0000
+
+	// _IOWR('!', 0, struct seccomp_notif) and _IOWR('!', 1, struct
+	// seccomp_notif_resp)
+	seccompIoctlNotifRecv = 0xc0502100
+	seccompIoctlNotifSend = 0xc0182101
+
+	// seccompMaxInstructions is the kernel's BPF_MAXINSNS
+	seccompMaxInstructions = 4096
+
+	// seccompPollInterval bounds how long the supervisor takes to notice
+	// it was stopped
+	seccompPollInterval = 100 * time.Millisecond
+)
+
+// Classic BPF opcodes used by the filter
+const (
+	bpfLdAbs  = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeq    = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgt    = 0x25 // BPF_JMP | BPF_JGT | BPF_K
+	bpfJge    = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfAnd    = 0x54 // BPF_ALU | BPF_AND | BPF_K
+	bpfRet    = 0x06 // BPF_RET | BPF_K
+	bpfNrOff  = 0
+	bpfArchOf = 4
+)
+
+// sockFilter is struct sock_filter
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+// sockFprog is struct sock_fprog
+type sockFprog struct {
+	len    uint16
+	filter *sockFilter
+}
+
+// seccompData is struct seccomp_data
+type seccompData struct {
+	nr   int32
+	arch uint32
+	ip   uint64
+	args [6]uint64
+}
+
+// seccompNotif is struct seccomp_notif
+type seccompNotif struct {
+	id    uint64
+	pid   uint32
+	flags uint32
+	data  seccompData
+}
+
+// seccompNotifResp is struct seccomp_notif_resp
+type seccompNotifResp struct {
+	id    uint64
+	val   int64
+	error int32
+	flags uint32
+}
+
+// pollFd is struct pollfd
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// compiledRule is a profile rule with its system calls resolved to numbers
+type compiledRule struct {
+	nrs    []int
+	args   []seccompArg
+	action seccompAction
+}
+
+// compiledSeccomp is a profile ready to install
+type compiledSeccomp struct {
+	filter        []sockFilter
+	rules         []compiledRule
+	defaultAction seccompAction
+	names         map[int]string
+}
+
+// probeSeccomp checks that the kernel supports seccomp user
+// notifications, which the sandbox relies on to report blocked calls
+func probeSeccomp() error {
+	action := uint32(seccompRetUserNotif)
+	_, _, errno := syscall.Syscall(uintptr(seccompSyscalls["seccomp"]), seccompGetActionAvail, 0, uintptr(unsafe.Pointer(&action)))
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ENOSYS, syscall.EINVAL, syscall.EOPNOTSUPP:
+		return errors.New("this kernel does not support seccomp user notifications (Linux 5.5 or later is required)")
+	default:
+		return fmt.Errorf("probing seccomp: %w", errno)
+	}
+}
+
+// compileSeccomp resolves the names in profile and builds its BPF filter.
+// Names the architecture does not have are skipped.
+func compileSeccomp(profile *seccompProfile) (*compiledSeccomp, error) {
+	c := &compiledSeccomp{defaultAction: profile.defaultAction, names: map[int]string{}}
+	for name, nr := range seccompSyscalls {
+		c.names[nr] = name
+	}
+	for _, rule := range profile.rules {
+		compiled := compiledRule{args: rule.args, action: rule.action}
+		for _, name := range rule.names {
+			if nr, ok := seccompSyscalls[name]; ok {
+				compiled.nrs = append(compiled.nrs, nr)
+			}
+		}
+		if len(compiled.nrs) > 0 {
+			c.rules = append(c.rules, compiled)
+		}
+	}
+
+	// Kill calls made through another architecture's system call table,
+	// and deny the x32 calls that share the x86-64 one
+	c.filter = []sockFilter{
+		{code: bpfLdAbs, k: bpfArchOf},
+		{code: bpfJeq, jt: 1, k: seccompAuditArch},
+		{code: bpfRet, k: seccompRetKillProcess},
+	}
+	if seccompX32Bit != 0 {
+		c.filter = append(c.filter,
+			sockFilter{code: bpfLdAbs, k: bpfNrOff},
+			sockFilter{code: bpfJge, jf: 1, k: seccompX32Bit},
+			sockFilter{code: bpfRet, k: seccompRetErrno | errnoEPERM},
+		)
+	}
+
+	for _, rule := range c.rules {
+		var checks []sockFilter
+		for i := len(rule.args) - 1; i >= 0; i-- {
+			checks = append(argCheck(rule.args[i], len(checks)+1), checks...)
+		}
+		for _, nr := range rule.nrs {
+			// Each block reloads the number, since argument checks
+			// overwrite the accumulator
+			c.filter = append(c.filter,
+				sockFilter{code: bpfLdAbs, k: bpfNrOff},
+				sockFilter{code: bpfJeq, jf: uint8(len(checks) + 1), k: uint32(nr)},
+			)
+			c.filter = append(c.filter, checks...)
+			c.filter = append(c.filter, sockFilter{code: bpfRet, k: rule.action.ret()})
+		}
+	}
+	c.filter = append(c.filter, sockFilter{code: bpfRet, k: c.defaultAction.ret()})
+
+	if len(c.filter) > seccompMaxInstructions {
+		return nil, fmt.Errorf("seccomp profile %s compiles to %d instructions, more than the kernel's limit of %d", profile.name, len(c.filter), seccompMaxInstructions)
+	}
+	return c, nil
+}
+
+// argCheck compiles an argument condition. When the condition fails it
+// jumps fail instructions past its own end; otherwise it falls through.
+// Arguments are 64-bit, so each half is compared in turn.
+func argCheck(arg seccompArg, fail int) []sockFilter {
+	lo := uint32(16 + 8*arg.Index)
+	hi := lo + 4
+	vlo, vhi := uint32(arg.Value), uint32(arg.Value>>32)
+	f := uint8(fail)
+
+	switch arg.Op {
+	case "SCMP_CMP_EQ":
+		return []sockFilter{
+			{code: bpfLdAbs, k: hi},
+			{code: bpfJeq, jf: 2 + f, k: vhi},
+			{code: bpfLdAbs, k: lo},
+			{code: bpfJeq, jf: f, k: vlo},
+		}
+	case "SCMP_CMP_NE":
+		return []sockFilter{
+			{code: bpfLdAbs, k: hi},
+			{code: bpfJeq, jf: 2, k: vhi},
+			{code: bpfLdAbs, k: lo},
+			{code: bpfJeq, jt: f, k: vlo},
+		}
+	case "SCMP_CMP_MASKED_EQ":
+		whi, wlo := uint32(arg.ValueTwo>>32), uint32(arg.ValueTwo)
+		return []sockFilter{
+			{code: bpfLdAbs, k: hi},
+			{code: bpfAnd, k: vhi},
+			{code: bpfJeq, jf: 3 + f, k: whi},
+			{code: bpfLdAbs, k: lo},
+			{code: bpfAnd, k: vlo},
+			{code: bpfJeq, jf: f, k: wlo},
+		}
+	case "SCMP_CMP_GT", "SCMP_CMP_GE":
+		last := sockFilter{code: bpfJgt, jf: f, k: vlo}
+		if arg.Op == "SCMP_CMP_GE" {
+			last.code = bpfJge
+		}
+		return []sockFilter{
+			{code: bpfLdAbs, k: hi},
+			{code: bpfJgt, jt: 3, k: vhi},
+			{code: bpfJeq, jf: 2 + f, k: vhi},
+			{code: bpfLdAbs, k: lo},
+			last,
+		}
+	default: // SCMP_CMP_LT, SCMP_CMP_LE
+		last := sockFilter{code: bpfJge, jt: f, k: vlo}
+		if arg.Op == "SCMP_CMP_LE" {
+			last.code = bpfJgt
+		}
+		return []sockFilter{
+			{code: bpfLdAbs, k: hi},
+			{code: bpfJgt, jt: 3 + f, k: vhi},
+			{code: bpfJeq, jf: 2, k: vhi},
+			{code: bpfLdAbs, k: lo},
+			last,
+		}
+	}
+}
+
+// ret returns the filter return value for an action. Calls the server
+// needs to see go to its user notification listener.
+func (a seccompAction) ret() uint32 {
+	switch a.kind {
+	case seccompAllow:
+		return seccompRetAllow
+	case seccompLog:
+		return seccompRetLog
+	case seccompErrno:
+		return seccompRetErrno | uint32(a.errno)&0xffff
+	default:
+		return seccompRetUserNotif
+	}
+}
+
+// actionFor decides a call the way the filter does
+func (c *compiledSeccomp) actionFor(nr int, args [6]uint64) seccompAction {
+	for _, rule := range c.rules {
+		if !slices.Contains(rule.nrs, nr) {
+			continue
+		}
+		matched := true
+		for _, arg := range rule.args {
+			if !arg.matches(args[arg.Index]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule.action
+		}
+	}
+	return c.defaultAction
+}
+
+// seccompSupervisor installs a filter and answers the calls it refers to
+// the server, recording the ones it denies
+type seccompSupervisor struct {
+	filter *compiledSeccomp
+
+	mu       sync.Mutex
+	listener int
+	starter  int
+	execed   bool
+	blocked  []string
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newSeccompSupervisor prepares a supervisor for filter
+func newSeccompSupervisor(filter *compiledSeccomp) *seccompSupervisor {
+	return &seccompSupervisor{filter: filter, listener: -1, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// install is the confinement step that filters the thread and starts
+// answering notifications
+func (s *seccompSupervisor) install(*exec.Cmd) error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("setting no_new_privs: %w", errno)
+	}
+
+	prog := sockFprog{len: uint16(len(s.filter.filter)), filter: &s.filter.filter[0]}
+	fd, _, errno := syscall.Syscall(uintptr(seccompSyscalls["seccomp"]), seccompSetModeFilter, seccompFlagNewListener, uintptr(unsafe.Pointer(&prog)))
+	if errno != 0 {
+		return fmt.Errorf("installing seccomp filter: %w", errno)
+	}
+	syscall.CloseOnExec(int(fd))
+
+	s.mu.Lock()
+	s.listener = int(fd)
+	s.starter = syscall.Gettid()
+	s.mu.Unlock()
+	go s.serve()
+	return nil
+}
+
+// Blocked returns the names of the calls denied so far, in the order they
+// were first seen
+func (s *seccompSupervisor) Blocked() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.blocked)
+}
+
+// Close stops answering notifications. Processes still running under the
+// filter then fail the calls it refers to the server.
+func (s *seccompSupervisor) Close() {
+	s.mu.Lock()
+	listener := s.listener
+	s.mu.Unlock()
+	if listener < 0 {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	syscall.Close(listener)
+}
+
+// serve answers notifications until Close is called or every process
+// under the filter has exited
+func (s *seccompSupervisor) serve() {
+	defer close(s.done)
+	timeout := syscall.NsecToTimespec(int64(seccompPollInterval))
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		fds := pollFd{fd: int32(s.listener), events: 0x1} // POLLIN
+		n, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&fds)), 1, uintptr(unsafe.Pointer(&timeout)), 0, 0, 0)
+		if errno == syscall.EINTR || n == 0 {
+			continue
+		}
+		if errno != 0 || fds.revents&0x1 == 0 {
+			// POLLHUP without POLLIN: nothing is left to supervise
+			return
+		}
+
+		var req seccompNotif
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(s.listener), seccompIoctlNotifRecv, uintptr(unsafe.Pointer(&req))); errno != 0 {
+			if errno == syscall.EINTR || errno == syscall.ENOENT {
+				continue
+			}
+			return
+		}
+		resp := s.decide(&req)
+		// ENOENT means the caller died in the meantime
+		syscall.Syscall(syscall.SYS_IOCTL, uintptr(s.listener), seccompIoctlNotifSend, uintptr(unsafe.Pointer(&resp)))
+	}
+}
+
+// decide answers one notification
+func (s *seccompSupervisor) decide(req *seccompNotif) seccompNotifResp {
+	nr := int(req.data.nr)
+	pid := int(req.pid)
+	action := s.filter.actionFor(nr, req.data.args)
+	allow := seccompNotifResp{id: req.id, flags: seccompUserNotifContinue}
+	deny := seccompNotifResp{id: req.id, error: -int32(max(action.errno, errnoEPERM))}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch action.kind {
+	case seccompAllow, seccompLog:
+		return allow
+	case seccompSpawn:
+		name := s.filter.names[nr]
+		isExec := name == "execve" || name == "execveat"
+		if pid == s.starter && !isExec {
+			return allow
+		}
+		if isExec && pid != s.starter && !s.execed {
+			s.execed = true
+			return allow
+		}
+	case seccompKill:
+		if pid != s.starter {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+	s.record(nr)
+	return deny
+}
+
+// record notes a denied call. The caller holds s.mu.
+func (s *seccompSupervisor) record(nr int) {
+	name, ok := s.filter.names[nr]
+	if !ok {
+		name = fmt.Sprintf("syscall %d", nr)
+	}
+	if !slices.Contains(s.blocked, name) {
+		s.blocked = append(s.blocked, name)
+	}
+}
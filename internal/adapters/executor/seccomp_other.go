@@ -0,0 +1,46 @@
+//go:build !linux || !(amd64 || arm64)
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errNoSeccomp reports that system call filtering is unavailable
+var errNoSeccomp = errors.New("seccomp filtering requires Linux on amd64 or arm64")
+
+// compiledSeccomp is a profile ready to install
+type compiledSeccomp struct{}
+
+// seccompSupervisor installs a filter and answers the calls it refers to
+// the server
+type seccompSupervisor struct{}
+
+// probeSeccomp reports that seccomp is unavailable
+func probeSeccomp() error {
+	return errNoSeccomp
+}
+
+// compileSeccomp fails: seccomp is unavailable
+func compileSeccomp(profile *seccompProfile) (*compiledSeccomp, error) {
+	return nil, errNoSeccomp
+}
+
+// newSeccompSupervisor prepares a supervisor for filter
+func newSeccompSupervisor(filter *compiledSeccomp) *seccompSupervisor {
+	return &seccompSupervisor{}
+}
+
+// install fails: seccomp is unavailable
+func (s *seccompSupervisor) install(*exec.Cmd) error {
+	return errNoSeccomp
+}
+
+// Blocked returns nothing: no calls are filtered
+func (s *seccompSupervisor) Blocked() []string {
+	return nil
+}
+
+// Close does nothing
+func (s *seccompSupervisor) Close() {}
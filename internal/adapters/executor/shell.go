@@ -4,21 +4,37 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/aravi/code_execution_mcp/internal/config"
 	"github.com/aravi/code_execution_mcp/internal/core/domain"
 	"github.com/aravi/code_execution_mcp/internal/core/ports"
 )
 
+// outOfMemoryMarkers are the messages runtimes print when an allocation
+// fails
+var outOfMemoryMarkers = []string{"MemoryError", "out of memory", "Cannot allocate memory"}
+
+// waitDelay bounds how long a killed process may keep its output open
+const waitDelay = 500 * time.Millisecond
+
 // ShellExecutor implements CodeExecutor for Bash/Zsh scripts
-type ShellExecutor struct{}
+type ShellExecutor struct {
+	policy   *shellPolicy
+	sandbox  *Sandbox
+	observer ports.Observer
+}
 
-// NewShellExecutor creates a new shell executor
-func NewShellExecutor() ports.CodeExecutor {
-	return &ShellExecutor{}
+// NewShellExecutor creates a new shell executor that checks bash scripts
+// against policy before running them in sandbox, and reports its steps to
+// observer
+func NewShellExecutor(policy config.ShellPolicy, sandbox *Sandbox, observer ports.Observer) ports.CodeExecutor {
+	return &ShellExecutor{policy: newShellPolicy(policy), sandbox: sandbox, observer: observerOrNop(observer)}
 }
 
 // Supports checks if this executor supports the given language
@@ -28,8 +44,24 @@ func (e *ShellExecutor) Supports(language string) bool {
 
 // Execute runs a bash/zsh script
 func (e *ShellExecutor) Execute(ctx context.Context, req domain.ExecutionRequest) (*domain.ExecutionResult, error) {
+	prepared, rejected, err := e.prepare(ctx, req)
+	if prepared == nil {
+		return rejected, err
+	}
+	defer prepared.Cleanup()
+
+	return runPrepared(e.observer, prepared, req)
+}
+
+// Prepare builds the shell process for a script without starting it
+func (e *ShellExecutor) Prepare(ctx context.Context, req domain.ExecutionRequest) (ports.PreparedProcess, *domain.ExecutionResult, error) {
+	return exposePrepared(e.prepare(ctx, req))
+}
+
+// prepare builds the shell process for a script
+func (e *ShellExecutor) prepare(ctx context.Context, req domain.ExecutionRequest) (*PreparedCommand, *domain.ExecutionResult, error) {
 	if strings.TrimSpace(req.Script) == "" {
-		return &domain.ExecutionResult{
+		return nil, &domain.ExecutionResult{
 			IsError:   true,
 			ErrorType: domain.ValidationError,
 			Stderr:    "Script cannot be empty",
@@ -38,7 +70,6 @@ func (e *ShellExecutor) Execute(ctx context.Context, req domain.ExecutionRequest
 
 	timeout := getTimeout(req.Timeout, 30)
 	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
 
 	// Determine shell based on OS
 	var shell, flag string
@@ -74,12 +105,31 @@ func (e *ShellExecutor) Execute(ctx context.Context, req domain.ExecutionRequest
 		}
 	}
 
+	// The policy understands bash syntax, so PowerShell scripts skip it
+	if e.policy != nil && flag == "-c" {
+		span := startStep(e.observer, ctx, "policy.check", req.Language)
+		violations := e.policy.Check(req.Script, req.WorkingDir)
+		span.SetAttributes(slog.Int("policy.violations", len(violations)))
+		if len(violations) > 0 {
+			rejected := policyRejection(req.Script, violations)
+			endStep(span, rejected)
+			cancel()
+			return nil, rejected, nil
+		}
+		span.End()
+	}
+
 	cmd := exec.CommandContext(ctx, shell, flag, fullScript)
 	if req.WorkingDir != "" {
 		cmd.Dir = req.WorkingDir
 	}
 
-	return executeCommand(cmd)
+	prepared := &PreparedCommand{Cmd: cmd, Ctx: ctx, Cleanup: cancel}
+	if rejected := e.sandbox.Apply(prepared, req); rejected != nil {
+		prepared.Cleanup()
+		return nil, rejected, nil
+	}
+	return prepared, nil, nil
 }
 
 // detectWindowsShell finds the best available shell on Windows
@@ -122,16 +172,84 @@ func shellQuoteArgs(args []string) string {
 	return strings.Join(quoted, " ")
 }
 
-// executeCommand runs a command and returns the result
-func executeCommand(cmd *exec.Cmd) (*domain.ExecutionResult, error) {
+// executeCommand runs a command and returns the result. ctx must be the
+// context the command was created with so that timeouts can be reported.
+func executeCommand(ctx context.Context, cmd *exec.Cmd, stdin string) (*domain.ExecutionResult, error) {
+	return runCommand(&PreparedCommand{Cmd: cmd, Ctx: ctx}, stdin), nil
+}
+
+// runCommand runs a prepared command to completion with stdin as its
+// standard input
+func runCommand(prepared *PreparedCommand, stdin string) *domain.ExecutionResult {
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	prepared.Cmd.Stdout = &stdout
+	prepared.Cmd.Stderr = &stderr
+	if stdin != "" {
+		prepared.Cmd.Stdin = strings.NewReader(stdin)
+	}
 
 	startTime := time.Now()
-	err := cmd.Run()
-	duration := time.Since(startTime)
+	err := prepared.start()
+	if err == nil {
+		err = prepared.Cmd.Wait()
+	} else {
+		fmt.Fprintf(&stderr, "Error starting process: %v", err)
+	}
+	return prepared.result(err, stdout.String(), stderr.String(), time.Since(startTime))
+}
 
+// recordUsage copies the CPU time and peak memory of an exited process,
+// and the signal that killed it
+func recordUsage(result *domain.ExecutionResult, state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	result.CPUTime = state.UserTime() + state.SystemTime()
+	result.PeakMemoryKB = peakMemoryKB(state)
+	result.Signal = exitSignal(state)
+}
+
+// runPrepared runs the prepared command for req to completion and records
+// the build step alongside the run
+func runPrepared(observer ports.Observer, prepared *PreparedCommand, req domain.ExecutionRequest) (*domain.ExecutionResult, error) {
+	span := startStep(observer, prepared.Ctx, "process.run", req.Language)
+	result := runCommand(prepared, req.Stdin)
+	endRun(observer, prepared.Ctx, span, req.Language, result)
+	return result, nil
+}
+
+// markTimeout reclassifies a failed result as a timeout when ctx expired
+func markTimeout(ctx context.Context, result *domain.ExecutionResult) {
+	if result.IsError && ctx.Err() == context.DeadlineExceeded {
+		result.ErrorType = domain.TimeoutError
+		if result.Stderr != "" && !strings.HasSuffix(result.Stderr, "\n") {
+			result.Stderr += "\n"
+		}
+		result.Stderr += "Execution timed out"
+	}
+}
+
+// markOutOfMemory flags a failed result whose process ran out of memory.
+// A SIGKILL counts only when ctx is live, since the server kills processes
+// with SIGKILL itself on timeouts and cancellation.
+func markOutOfMemory(ctx context.Context, result *domain.ExecutionResult) {
+	if !result.IsError || result.ErrorType != domain.RuntimeError {
+		return
+	}
+	if result.Signal == "killed" && ctx.Err() == nil {
+		result.OutOfMemory = true
+		return
+	}
+	for _, marker := range outOfMemoryMarkers {
+		if strings.Contains(result.Stderr, marker) {
+			result.OutOfMemory = true
+			return
+		}
+	}
+}
+
+// newResult builds an ExecutionResult from the outcome of running a process
+func newResult(err error, stdout, stderr string, duration time.Duration) *domain.ExecutionResult {
 	exitCode := 0
 	errorType := domain.NoError
 
@@ -147,12 +265,12 @@ func executeCommand(cmd *exec.Cmd) (*domain.ExecutionResult, error) {
 
 	return &domain.ExecutionResult{
 		ExitCode:  exitCode,
-		Stdout:    stdout.String(),
-		Stderr:    stderr.String(),
+		Stdout:    stdout,
+		Stderr:    stderr,
 		Duration:  duration,
 		IsError:   exitCode != 0,
 		ErrorType: errorType,
-	}, nil
+	}
 }
 
 // getTimeout returns a valid timeout duration
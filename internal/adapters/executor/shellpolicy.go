@@ -0,0 +1,568 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Shell policy rules, reported with each violation
+const (
+	ruleSyntax            = "syntax"
+	ruleDeniedCommand     = "denied_command"
+	ruleCommandNotAllowed = "command_not_allowed"
+	ruleDynamicCommand    = "dynamic_command"
+	ruleRemoteScript      = "remote_script"
+	ruleDestructiveDelete = "destructive_delete"
+	ruleWriteOutside      = "write_outside_workspace"
+)
+
+// shellBuiltins may always run when an allow list is configured
+var shellBuiltins = setOf(
+	".", ":", "[", "alias", "bg", "break", "builtin", "cd", "command", "continue",
+	"declare", "dirs", "echo", "eval", "exit", "export", "false", "fg", "getopts",
+	"hash", "jobs", "let", "local", "mapfile", "popd", "printf", "pushd", "pwd",
+	"read", "readarray", "readonly", "return", "set", "shift", "shopt", "source",
+	"test", "times", "trap", "true", "type", "typeset", "ulimit", "umask",
+	"unalias", "unset", "wait",
+)
+
+// commandWrappers run the command given in their arguments. The value is
+// the number of positional arguments taken before that command.
+var commandWrappers = map[string]int{
+	"builtin": 0, "command": 0, "doas": 0, "env": 0, "exec": 0, "nice": 0,
+	"nohup": 0, "setsid": 0, "stdbuf": 0, "sudo": 0, "time": 0, "timeout": 1,
+	"xargs": 0,
+}
+
+// scriptInterpreters read a program from stdin when given no script file
+var scriptInterpreters = setOf("bash", "dash", "fish", "ksh", "node", "perl", "python", "python3", "ruby", "sh", "zsh")
+
+// shellInterpreters accept a script string with -c
+var shellInterpreters = setOf("bash", "dash", "ksh", "sh", "zsh")
+
+// downloaders fetch remote content
+var downloaders = setOf("curl", "fetch", "wget")
+
+// protectedDirs are never valid targets of a recursive delete, and
+// neither is anything beneath them but / outside the workspace
+var protectedDirs = setOf(
+	"/", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/opt",
+	"/proc", "/root", "/sbin", "/srv", "/sys", "/usr", "/var",
+)
+
+// shellPolicy checks scripts against a config.ShellPolicy
+type shellPolicy struct {
+	cfg   config.ShellPolicy
+	deny  map[string]bool
+	allow map[string]bool
+	home  string
+}
+
+// newShellPolicy returns the checker for cfg, or nil when it is disabled
+func newShellPolicy(cfg config.ShellPolicy) *shellPolicy {
+	if !cfg.Enabled {
+		return nil
+	}
+	home, _ := os.UserHomeDir()
+	p := &shellPolicy{cfg: cfg, deny: setOf(cfg.DenyCommands...), home: home}
+	if len(cfg.AllowCommands) > 0 {
+		p.allow = setOf(cfg.AllowCommands...)
+	}
+	return p
+}
+
+// Check parses script and returns the violations it contains. workDir is
+// the directory the script will run in.
+func (p *shellPolicy) Check(script, workDir string) []domain.PolicyViolation {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return []domain.PolicyViolation{syntaxViolation(err)}
+	}
+
+	if workDir == "" {
+		workDir, _ = os.Getwd()
+	}
+	writable := []string{filepath.Clean(workDir), filepath.Clean(os.TempDir())}
+	for _, dir := range p.cfg.WritableDirs {
+		writable = append(writable, filepath.Clean(dir))
+	}
+
+	w := &shellPolicyWalk{policy: p, workDir: workDir, writable: writable, functions: map[string]bool{}}
+	w.walk(file)
+	return w.violations
+}
+
+// shellPolicyWalk collects the violations in one script. Violations found
+// in nested scripts (bash -c and eval strings) are reported at the
+// position of the command that runs them.
+type shellPolicyWalk struct {
+	policy     *shellPolicy
+	workDir    string
+	writable   []string
+	functions  map[string]bool
+	nestedAt   *syntax.Pos
+	violations []domain.PolicyViolation
+}
+
+// walk checks every node of a parsed script
+func (w *shellPolicyWalk) walk(file *syntax.File) {
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if fn, ok := node.(*syntax.FuncDecl); ok {
+			w.functions[fn.Name.Value] = true
+		}
+		return true
+	})
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.CallExpr:
+			w.checkCall(node)
+		case *syntax.BinaryCmd:
+			if node.Op == syntax.Pipe || node.Op == syntax.PipeAll {
+				w.checkPipe(node)
+			}
+		case *syntax.Redirect:
+			w.checkRedirect(node)
+		}
+		return true
+	})
+}
+
+// report records a violation at pos, or at the command running the
+// nested script being walked
+func (w *shellPolicyWalk) report(pos syntax.Pos, rule, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if w.nestedAt != nil {
+		pos = *w.nestedAt
+		message = "in nested script: " + message
+	}
+	w.violations = append(w.violations, domain.PolicyViolation{
+		Rule:    rule,
+		Message: message,
+		Line:    int(pos.Line()),
+		Column:  int(pos.Col()),
+	})
+}
+
+// shellCommand is one command of a simple command, after unwrapping
+// wrappers such as sudo, env and xargs
+type shellCommand struct {
+	name string
+	pos  syntax.Pos
+	args []*syntax.Word
+}
+
+// commandChain returns the commands a call runs: the command itself and,
+// for wrappers, the command they run. ok is false when a command name is
+// not literal.
+func commandChain(args []*syntax.Word) (chain []shellCommand, ok bool) {
+	for len(args) > 0 {
+		value, literal := wordValue(args[0])
+		if !literal {
+			return chain, false
+		}
+		name := path.Base(value)
+		chain = append(chain, shellCommand{name: name, pos: args[0].Pos(), args: args[1:]})
+
+		skip, wrapper := commandWrappers[name]
+		if !wrapper {
+			return chain, true
+		}
+		rest := args[1:]
+		args = nil
+		for i, arg := range rest {
+			value, _ := wordValue(arg)
+			if strings.HasPrefix(value, "-") || (name == "env" && strings.Contains(value, "=")) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			args = rest[i:]
+			break
+		}
+	}
+	return chain, true
+}
+
+// checkCall applies the command rules to a simple command
+func (w *shellPolicyWalk) checkCall(call *syntax.CallExpr) {
+	if len(call.Args) == 0 {
+		return
+	}
+	chain, ok := commandChain(call.Args)
+	if !ok && w.policy.allow != nil {
+		w.report(call.Args[0].Pos(), ruleDynamicCommand, "the command name is computed at run time, so it cannot be checked against the allowed commands")
+	}
+
+	for _, cmd := range chain {
+		switch {
+		case w.policy.deny[cmd.name]:
+			w.report(cmd.pos, ruleDeniedCommand, "%q is not allowed", cmd.name)
+		case w.policy.allow != nil && !w.policy.allow[cmd.name] && !shellBuiltins[cmd.name] && !w.functions[cmd.name]:
+			w.report(cmd.pos, ruleCommandNotAllowed, "%q is not in the allowed commands", cmd.name)
+		}
+
+		if w.policy.cfg.DenyRemoteScripts && (scriptInterpreters[cmd.name] || cmd.name == "eval" || cmd.name == "source" || cmd.name == ".") {
+			for _, arg := range cmd.args {
+				if runsDownload(arg) {
+					w.report(arg.Pos(), ruleRemoteScript, "%s runs code downloaded by the script", cmd.name)
+				}
+			}
+		}
+		if w.policy.cfg.DenyDestructiveDeletes && cmd.name == "rm" {
+			w.checkRemove(cmd)
+		}
+		if w.policy.cfg.RestrictWrites {
+			for _, target := range writeTargets(cmd) {
+				w.checkWrite(target)
+			}
+		}
+		w.checkNested(cmd)
+	}
+}
+
+// checkNested walks the literal scripts passed to bash -c and eval
+func (w *shellPolicyWalk) checkNested(cmd shellCommand) {
+	var script string
+	switch {
+	case cmd.name == "eval":
+		parts := make([]string, 0, len(cmd.args))
+		for _, arg := range cmd.args {
+			value, ok := wordValue(arg)
+			if !ok {
+				return
+			}
+			parts = append(parts, value)
+		}
+		script = strings.Join(parts, " ")
+	case shellInterpreters[cmd.name]:
+		for i, arg := range cmd.args {
+			if value, _ := wordValue(arg); value == "-c" && i+1 < len(cmd.args) {
+				script, _ = wordValue(cmd.args[i+1])
+				break
+			}
+		}
+	}
+	if script == "" {
+		return
+	}
+
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(script), "")
+	if err != nil {
+		return
+	}
+	outer := w.nestedAt
+	if outer == nil {
+		w.nestedAt = &cmd.pos
+	}
+	w.walk(file)
+	w.nestedAt = outer
+}
+
+// checkPipe flags downloads piped into an interpreter, as in curl | sh
+func (w *shellPolicyWalk) checkPipe(pipe *syntax.BinaryCmd) {
+	if !w.policy.cfg.DenyRemoteScripts {
+		return
+	}
+	call, ok := pipe.Y.Cmd.(*syntax.CallExpr)
+	if !ok || !containsDownload(pipe.X) {
+		return
+	}
+	chain, _ := commandChain(call.Args)
+	for _, cmd := range chain {
+		if scriptInterpreters[cmd.name] && readsStdin(cmd) {
+			w.report(pipe.OpPos, ruleRemoteScript, "downloaded content is piped into %s", cmd.name)
+		}
+	}
+}
+
+// checkRemove flags recursive deletes of the root, home or system
+// directories, and of anything beneath home or a system directory that is
+// outside the workspace
+func (w *shellPolicyWalk) checkRemove(cmd shellCommand) {
+	recursive := false
+	var targets []*syntax.Word
+	for _, arg := range cmd.args {
+		value, _ := wordValue(arg)
+		switch {
+		case value == "--no-preserve-root":
+			w.report(arg.Pos(), ruleDestructiveDelete, "rm --no-preserve-root is not allowed")
+		case value == "--recursive":
+			recursive = true
+		case strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "--"):
+			recursive = recursive || strings.ContainsAny(value, "rR")
+		default:
+			targets = append(targets, arg)
+		}
+	}
+	if !recursive {
+		return
+	}
+	for _, target := range targets {
+		dir, ok := w.pathValue(target)
+		if !ok {
+			continue
+		}
+		dir = strings.TrimSuffix(dir, "/*")
+		if dir == "" {
+			dir = "/"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(w.workDir, dir)
+		}
+		clean := filepath.Clean(dir)
+		switch {
+		case protectedDirs[clean] || (w.policy.home != "" && clean == w.policy.home):
+			w.report(target.Pos(), ruleDestructiveDelete, "recursive delete of %s", clean)
+		case w.underProtected(clean) && !w.inWorkspace(clean):
+			w.report(target.Pos(), ruleDestructiveDelete, "recursive delete of %s, outside the workspace", clean)
+		}
+	}
+}
+
+// underProtected reports whether path lies beneath home or a protected
+// directory other than /
+func (w *shellPolicyWalk) underProtected(path string) bool {
+	if w.policy.home != "" && within(w.policy.home, path) {
+		return true
+	}
+	for dir := path; dir != "/" && dir != "."; {
+		dir = filepath.Dir(dir)
+		if dir != "/" && protectedDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// inWorkspace reports whether path lies in a writable directory
+func (w *shellPolicyWalk) inWorkspace(path string) bool {
+	for _, dir := range w.writable {
+		if within(dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or lies beneath it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkRedirect checks the target of an output redirection
+func (w *shellPolicyWalk) checkRedirect(redirect *syntax.Redirect) {
+	if !w.policy.cfg.RestrictWrites || redirect.Word == nil {
+		return
+	}
+	switch redirect.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+		w.checkWrite(redirect.Word)
+	}
+}
+
+// checkWrite flags a literal path outside the writable directories
+func (w *shellPolicyWalk) checkWrite(target *syntax.Word) {
+	file, ok := w.pathValue(target)
+	if !ok || file == "" || file == "-" {
+		return
+	}
+	if file == "/dev/null" || file == "/dev/stdout" || file == "/dev/stderr" || file == "/dev/tty" || strings.HasPrefix(file, "/dev/fd/") {
+		return
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(w.workDir, file)
+	}
+	file = filepath.Clean(file)
+	if w.inWorkspace(file) {
+		return
+	}
+	w.report(target.Pos(), ruleWriteOutside, "writes to %s, outside the workspace", file)
+}
+
+// pathValue returns the literal value of a path word, expanding a leading
+// ~, $HOME or ${HOME}, quoted or not
+func (w *shellPolicyWalk) pathValue(word *syntax.Word) (string, bool) {
+	var parts []syntax.WordPart
+	for _, part := range word.Parts {
+		if quoted, ok := part.(*syntax.DblQuoted); ok {
+			parts = append(parts, quoted.Parts...)
+		} else {
+			parts = append(parts, part)
+		}
+	}
+
+	var value strings.Builder
+	for i, part := range parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			value.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			value.WriteString(part.Value)
+		case *syntax.ParamExp:
+			if i > 0 || !isPlainParam(part, "HOME") || w.policy.home == "" {
+				return "", false
+			}
+			value.WriteString(w.policy.home)
+		default:
+			return "", false
+		}
+	}
+
+	path := value.String()
+	if w.policy.home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+		path = w.policy.home + path[1:]
+	}
+	return path, true
+}
+
+// isPlainParam reports whether param is a bare $name or ${name}
+func isPlainParam(param *syntax.ParamExp, name string) bool {
+	return param.Param != nil && param.Param.Value == name && !param.Excl && !param.Length && !param.Width &&
+		param.Index == nil && param.Slice == nil && param.Repl == nil && param.Names == 0 && param.Exp == nil
+}
+
+// writeTargets returns the arguments of cmd that name files it writes to
+func writeTargets(cmd shellCommand) []*syntax.Word {
+	var positional []*syntax.Word
+	var ddOutputs []*syntax.Word
+	for _, arg := range cmd.args {
+		value, _ := wordValue(arg)
+		if strings.HasPrefix(value, "of=") {
+			ddOutputs = append(ddOutputs, &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{ValuePos: arg.Pos(), Value: value[len("of="):]}}})
+		}
+		if !strings.HasPrefix(value, "-") {
+			positional = append(positional, arg)
+		}
+	}
+
+	switch cmd.name {
+	case "tee", "touch", "mkdir", "rm", "rmdir", "truncate", "shred", "unlink":
+		return positional
+	case "chmod", "chown", "chgrp":
+		if len(positional) > 1 {
+			return positional[1:]
+		}
+	case "cp", "mv", "ln", "install", "rsync":
+		if len(positional) > 1 {
+			return positional[len(positional)-1:]
+		}
+	case "dd":
+		return ddOutputs
+	}
+	return nil
+}
+
+// runsDownload reports whether a word's command or process substitutions
+// download something, as in bash <(curl ...) or eval "$(wget ...)"
+func runsDownload(word *syntax.Word) bool {
+	found := false
+	syntax.Walk(word, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.CmdSubst:
+			for _, stmt := range node.Stmts {
+				found = found || containsDownload(stmt)
+			}
+		case *syntax.ProcSubst:
+			for _, stmt := range node.Stmts {
+				found = found || containsDownload(stmt)
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// containsDownload reports whether node runs a downloader anywhere
+func containsDownload(node syntax.Node) bool {
+	found := false
+	syntax.Walk(node, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok {
+			chain, _ := commandChain(call.Args)
+			for _, cmd := range chain {
+				found = found || downloaders[cmd.name]
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// readsStdin reports whether an interpreter is invoked without a script,
+// so that it runs whatever arrives on stdin
+func readsStdin(cmd shellCommand) bool {
+	for _, arg := range cmd.args {
+		value, ok := wordValue(arg)
+		if !ok {
+			return false
+		}
+		switch {
+		case value == "-s" || value == "-":
+			return true
+		case value == "-c" || value == "-e" || !strings.HasPrefix(value, "-"):
+			return false
+		}
+	}
+	return true
+}
+
+// wordValue returns the value of a word made only of literals and quoted
+// literals
+func wordValue(word *syntax.Word) (string, bool) {
+	var value strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			value.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			value.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				value.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return value.String(), true
+}
+
+// syntaxViolation reports a script the parser rejected
+func syntaxViolation(err error) domain.PolicyViolation {
+	violation := domain.PolicyViolation{Rule: ruleSyntax, Message: err.Error()}
+	var parseErr syntax.ParseError
+	var langErr syntax.LangError
+	switch {
+	case errors.As(err, &parseErr):
+		violation.Message = parseErr.Text
+		violation.Line, violation.Column = int(parseErr.Pos.Line()), int(parseErr.Pos.Col())
+	case errors.As(err, &langErr):
+		violation.Message = fmt.Sprintf("%s is not supported by bash", langErr.Feature)
+		violation.Line, violation.Column = int(langErr.Pos.Line()), int(langErr.Pos.Col())
+	}
+	return violation
+}
+
+// setOf builds a lookup set from names
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// toolchainProbeTimeout bounds each command run to probe a toolchain
+const toolchainProbeTimeout = 10 * time.Second
+
+// pythonPackagesOfInterest are the packages whose versions are reported
+// for the Python toolchain. coverage is used for coverage reports when
+// installed.
+var pythonPackagesOfInterest = []string{"coverage", "numpy", "pandas", "scipy", "matplotlib", "requests", "sympy"}
+
+// goEnvOfInterest are the Go settings reported for the Go toolchain
+var goEnvOfInterest = []string{"GOVERSION", "GOOS", "GOARCH", "GOROOT", "GOPATH", "GOMODCACHE", "GOPROXY", "GOFLAGS", "CGO_ENABLED"}
+
+// pythonPackageVersions prints the installed version of each package
+// named in argv as a JSON object, with "" for those not installed
+const pythonPackageVersions = `
+import json, sys
+from importlib import metadata
+versions = {}
+for name in sys.argv[1:]:
+    try:
+        versions[name] = metadata.version(name)
+    except metadata.PackageNotFoundError:
+        versions[name] = ""
+print(json.dumps(versions))
+`
+
+// Toolchains holds the toolchains found on the host when it was probed
+type Toolchains struct {
+	toolchains []domain.Toolchain
+}
+
+// toolchainProber is implemented by executors that can describe the
+// toolchain they run code with, as they run it
+type toolchainProber interface {
+	probeToolchain(ctx context.Context) domain.Toolchain
+}
+
+// ProbeToolchains looks for the shells, and asks the Python and Go
+// executors among executors for the interpreter and toolchain they run
+// code with, noting their versions. A language with no executor given is
+// reported as unavailable.
+func ProbeToolchains(ctx context.Context, executors ...ports.CodeExecutor) *Toolchains {
+	shell := "bash"
+	if runtime.GOOS == "windows" {
+		shell, _ = detectWindowsShell()
+	}
+	return &Toolchains{toolchains: []domain.Toolchain{
+		probeShell(ctx, "bash", shell, false),
+		probeShell(ctx, "zsh", "zsh", true),
+		probeShell(ctx, "sh", "sh", true),
+		probeExecutor(ctx, executors, "python"),
+		probeExecutor(ctx, executors, "go"),
+	}}
+}
+
+// probeExecutor describes the toolchain of the executor supporting
+// language
+func probeExecutor(ctx context.Context, executors []ports.CodeExecutor, language string) domain.Toolchain {
+	for _, executor := range executors {
+		if prober, ok := executor.(toolchainProber); ok && executor.Supports(language) {
+			return prober.probeToolchain(ctx)
+		}
+	}
+	return domain.Toolchain{Language: language, Name: language, Detail: "no executor for " + language}
+}
+
+// Toolchains returns the shells, then Python, then Go
+func (t *Toolchains) Toolchains() []domain.Toolchain {
+	return t.toolchains
+}
+
+// lookTool finds name on the PATH and describes it as the toolchain for
+// language
+func lookTool(language, name string, optional bool) domain.Toolchain {
+	toolchain := domain.Toolchain{Language: language, Name: filepath.Base(name), Optional: optional}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		toolchain.Detail = err.Error()
+		return toolchain
+	}
+	toolchain.Path, toolchain.Available = path, true
+	return toolchain
+}
+
+// probeTool runs the toolchain's program with args in env, or the
+// server's environment when env is nil, and returns its output
+func probeTool(ctx context.Context, toolchain domain.Toolchain, env []string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolchainProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, toolchain.Path, args...)
+	cmd.Env = env
+	return runQuiet(cmd)
+}
+
+// probeShell describes a shell, with the version it reports when it
+// reports one
+func probeShell(ctx context.Context, language, name string, optional bool) domain.Toolchain {
+	toolchain := lookTool(language, name, optional)
+	if !toolchain.Available || language == "sh" {
+		return toolchain
+	}
+	out, err := probeTool(ctx, toolchain, nil, "--version")
+	if err != nil {
+		toolchain.Detail = err.Error()
+		return toolchain
+	}
+	firstLine, _, _ := strings.Cut(string(out), "\n")
+	toolchain.Version = strings.TrimSpace(firstLine)
+	return toolchain
+}
+
+// probeToolchain describes the interpreter the executor runs scripts with
+// and the packages of interest installed for it
+func (e *PythonExecutor) probeToolchain(ctx context.Context) domain.Toolchain {
+	toolchain := lookTool("python", e.interpreter, false)
+	if !toolchain.Available {
+		return toolchain
+	}
+	out, err := probeTool(ctx, toolchain, nil, "--version")
+	if err != nil {
+		// A PATH entry that is not a working interpreter, such as a
+		// version manager shim with no version selected
+		toolchain.Available, toolchain.Detail = false, err.Error()
+		return toolchain
+	}
+	toolchain.Version = strings.TrimPrefix(strings.TrimSpace(string(out)), "Python ")
+
+	out, err = probeTool(ctx, toolchain, nil, append([]string{"-c", pythonPackageVersions}, pythonPackagesOfInterest...)...)
+	if err != nil {
+		toolchain.Detail = "listing packages: " + err.Error()
+		return toolchain
+	}
+	if err := json.Unmarshal(out, &toolchain.Packages); err != nil {
+		toolchain.Detail = "listing packages: " + err.Error()
+	}
+	return toolchain
+}
+
+// probeToolchain describes the Go toolchain and the environment the
+// executor builds with, module settings included
+func (e *GolangExecutor) probeToolchain(ctx context.Context) domain.Toolchain {
+	toolchain := lookTool("go", "go", false)
+	if !toolchain.Available {
+		return toolchain
+	}
+	out, err := probeTool(ctx, toolchain, e.env, append([]string{"env", "-json"}, goEnvOfInterest...)...)
+	if err == nil {
+		err = json.Unmarshal(out, &toolchain.Env)
+	}
+	if err != nil {
+		toolchain.Available, toolchain.Detail = false, "go env: "+err.Error()
+		return toolchain
+	}
+	toolchain.Version = toolchain.Env["GOVERSION"]
+	return toolchain
+}
@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// uidPool hands out UIDs from a range to client sessions. A session keeps
+// its UID until it closes and the sandbox has killed the processes and
+// deleted the files it left behind, so that nothing passes to the next
+// session given the UID; while every UID is held, further sessions get
+// none.
+type uidPool struct {
+	start, size int
+
+	mu       sync.Mutex
+	sessions map[string]int
+}
+
+// newUIDPool creates a pool of the size UIDs beginning at start
+func newUIDPool(start, size int) *uidPool {
+	return &uidPool{
+		start:    start,
+		size:     size,
+		sessions: make(map[string]int),
+	}
+}
+
+// acquire returns the UID of session, assigning one if it has none. It
+// returns false when session has none and every UID is taken.
+func (p *uidPool) acquire(session string) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if uid, ok := p.sessions[session]; ok {
+		return uid, true
+	}
+	if len(p.sessions) >= p.size {
+		return 0, false
+	}
+
+	taken := make(map[int]bool, len(p.sessions))
+	for _, u := range p.sessions {
+		taken[u] = true
+	}
+	for uid := p.start; uid < p.start+p.size; uid++ {
+		if !taken[uid] {
+			p.sessions[session] = uid
+			return uid, true
+		}
+	}
+	return 0, false
+}
+
+// lookup returns the UID session holds, if any
+func (p *uidPool) lookup(session string) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	uid, ok := p.sessions[session]
+	return uid, ok
+}
+
+// release returns the UID of session to the pool
+func (p *uidPool) release(session string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sessions, session)
+}
+
+// chownTree gives path, and everything beneath it when it is a directory,
+// to uid and gid
+func chownTree(path string, uid, gid int) error {
+	return filepath.WalkDir(path, func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(p, uid, gid)
+	})
+}
+
+// withEnv returns env with name set to value
+func withEnv(env []string, name, value string) []string {
+	var kept []string
+	for _, entry := range env {
+		if !strings.HasPrefix(entry, name+"=") {
+			kept = append(kept, entry)
+		}
+	}
+	return append(kept, name+"="+value)
+}
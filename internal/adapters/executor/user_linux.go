@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// killUserAttempts bounds how often killUser looks for processes again,
+// since a process may fork while the others are killed
+const killUserAttempts = 10
+
+// runAs returns the step that starts the process as uid and gid, with no
+// supplementary groups, and with umask as its file creation mask. A zero
+// uid keeps the server's user and a negative umask keeps its mask.
+func runAs(uid, gid, umask int) confinement {
+	return func(cmd *exec.Cmd) error {
+		if uid != 0 {
+			if cmd.SysProcAttr == nil {
+				cmd.SysProcAttr = &syscall.SysProcAttr{}
+			}
+			cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+		}
+		if umask >= 0 {
+			// The threads of a process share one umask until a thread
+			// stops sharing its filesystem attributes
+			if err := syscall.Unshare(syscall.CLONE_FS); err != nil {
+				return fmt.Errorf("setting umask: %w", err)
+			}
+			syscall.Umask(umask)
+		}
+		return nil
+	}
+}
+
+// killUser kills every process whose real, effective or saved UID is uid
+func killUser(uid int) error {
+	if uid == 0 {
+		return errors.New("refusing to kill the processes of root")
+	}
+	for attempt := 0; attempt < killUserAttempts; attempt++ {
+		pids, err := userProcesses(uid)
+		if err != nil {
+			return err
+		}
+		if len(pids) == 0 {
+			return nil
+		}
+		for _, pid := range pids {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("processes still running after %d attempts", killUserAttempts)
+}
+
+// userProcesses lists the processes running as uid. Zombies are left out,
+// since they have already exited.
+func userProcesses(uid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if running, owned := processOwner(pid, uid); running && owned {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// processOwner reports whether pid is running rather than a zombie, and
+// whether any of its real, effective and saved UIDs is uid. A process
+// that has exited since /proc was listed is neither.
+func processOwner(pid, uid int) (running, owned bool) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+
+	running = true
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), ":")
+		fields := strings.Fields(value)
+		switch key {
+		case "State":
+			running = len(fields) == 0 || fields[0] != "Z"
+		case "Uid":
+			for _, field := range fields[:min(3, len(fields))] {
+				if field == strconv.Itoa(uid) {
+					owned = true
+				}
+			}
+		}
+	}
+	return running, owned
+}
+
+// removeOwned deletes everything beneath dir that uid owns. A missing dir
+// has nothing to delete.
+func removeOwned(dir string, uid int) error {
+	if uid == 0 {
+		return errors.New("refusing to delete the files of root")
+	}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			// Gone since it was listed
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != uid {
+			return nil
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveOwned(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("handing files to another user requires root")
+	}
+	const uid = 61000
+	dir := t.TempDir()
+	write := func(path string, owner int) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Lchown(path, owner, owner); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("owned", uid)
+	write("kept", 0)
+	write("other", uid+1)
+	write("shared/owned", uid)
+	write("shared/kept", 0)
+	write("session/nested/owned", uid)
+	if err := chownTree(filepath.Join(dir, "session"), uid, uid); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeOwned(dir, uid); err != nil {
+		t.Fatalf("removeOwned: %v", err)
+	}
+	for path, want := range map[string]bool{
+		"owned":        false,
+		"kept":         true,
+		"other":        true,
+		"shared":       true,
+		"shared/owned": false,
+		"shared/kept":  true,
+		"session":      false,
+	} {
+		_, err := os.Lstat(filepath.Join(dir, path))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists: %v, want %v", path, exists, want)
+		}
+	}
+
+	if err := removeOwned(filepath.Join(dir, "missing"), uid); err != nil {
+		t.Errorf("removeOwned on a missing directory: %v", err)
+	}
+	if err := removeOwned(dir, 0); err == nil {
+		t.Errorf("removeOwned accepted root")
+	}
+}
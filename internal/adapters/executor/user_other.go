@@ -0,0 +1,26 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// runAs fails: switching users and setting a per-process umask rely on
+// Linux
+func runAs(uid, gid, umask int) confinement {
+	return func(*exec.Cmd) error {
+		return errors.New("running executions as another user or with their own umask requires Linux")
+	}
+}
+
+// killUser fails: the UID pool relies on Linux
+func killUser(uid int) error {
+	return errors.New("killing the processes of a user requires Linux")
+}
+
+// removeOwned fails: the UID pool relies on Linux
+func removeOwned(dir string, uid int) error {
+	return errors.New("deleting the files of a user requires Linux")
+}
@@ -0,0 +1,56 @@
+package executor
+
+import "testing"
+
+func TestUIDPoolNeverReassigns(t *testing.T) {
+	pool := newUIDPool(61000, 2)
+	steps := []struct {
+		session string
+		uid     int
+		ok      bool
+	}{
+		{"a", 61000, true},
+		{"b", 61001, true},
+		{"a", 61000, true},
+		{"c", 0, false},
+		{"b", 61001, true},
+		{"c", 0, false},
+	}
+	for i, step := range steps {
+		uid, ok := pool.acquire(step.session)
+		if uid != step.uid || ok != step.ok {
+			t.Errorf("step %d: acquire(%q) = %d, %v; want %d, %v", i+1, step.session, uid, ok, step.uid, step.ok)
+		}
+	}
+}
+
+func TestUIDPoolReleasesAndReuses(t *testing.T) {
+	pool := newUIDPool(61000, 2)
+	acquire := func(session string, wantUID int, wantOK bool) {
+		t.Helper()
+		if uid, ok := pool.acquire(session); uid != wantUID || ok != wantOK {
+			t.Errorf("acquire(%q) = %d, %v; want %d, %v", session, uid, ok, wantUID, wantOK)
+		}
+	}
+
+	acquire("a", 61000, true)
+	acquire("b", 61001, true)
+	acquire("c", 0, false)
+
+	pool.release("a")
+	if _, ok := pool.lookup("a"); ok {
+		t.Errorf("lookup(%q) found a UID after release", "a")
+	}
+	acquire("c", 61000, true)
+	acquire("b", 61001, true)
+	acquire("a", 0, false)
+
+	// Releasing a session that holds nothing changes nothing
+	pool.release("unknown")
+	acquire("d", 0, false)
+
+	pool.release("b")
+	pool.release("c")
+	acquire("d", 61000, true)
+	acquire("a", 61001, true)
+}
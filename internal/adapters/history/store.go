@@ -0,0 +1,184 @@
+// Package history keeps past executions in memory and, optionally, in a
+// directory of JSON files.
+package history
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// Store holds the newest executions, up to a maximum count. When backed by
+// a directory, each entry is also written to <id>.json there, and the
+// directory is read back when the store is created.
+type Store struct {
+	dir        string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries []domain.HistoryEntry // oldest first
+}
+
+// NewStore creates the store described by cfg, loading the entries saved
+// in its directory
+func NewStore(cfg config.HistoryConfig) (*Store, error) {
+	s := &Store{dir: cfg.Dir, maxEntries: cfg.MaxEntries}
+	if s.maxEntries <= 0 {
+		s.maxEntries = 100
+	}
+	if s.dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating history directory: %w", err)
+	}
+	entries, err := loadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	s.entries = entries
+	s.trim()
+	return s, nil
+}
+
+// Load reads the entries saved in a history directory, or in a file
+// holding one entry or one entry per line, oldest first
+func Load(path string) ([]domain.HistoryEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return loadDir(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []domain.HistoryEntry
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var entry domain.HistoryEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if entry.ID == "" || entry.Result == nil {
+			return nil, fmt.Errorf("%s holds something other than history entries; audit logs record no code and cannot be replayed", path)
+		}
+		entries = append(entries, entry)
+	}
+	sortByTime(entries)
+	return entries, nil
+}
+
+// loadDir reads the entries saved in dir, skipping unreadable files
+func loadDir(dir string) ([]domain.HistoryEntry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []domain.HistoryEntry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading history: %w", err)
+		}
+		var entry domain.HistoryEntry
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil || entry.ID != strings.TrimSuffix(filepath.Base(file), ".json") {
+			slog.Warn("Skipping unreadable history entry", "file", file, "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sortByTime(entries)
+	return entries, nil
+}
+
+// sortByTime orders entries oldest first
+func sortByTime(entries []domain.HistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+}
+
+// Record stores entry under a new ID, dropping the oldest entries beyond
+// the maximum
+func (s *Store) Record(entry domain.HistoryEntry) (domain.HistoryEntry, error) {
+	var id [6]byte
+	rand.Read(id[:])
+	entry.ID = hex.EncodeToString(id[:])
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	if s.dir != "" {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return entry, err
+		}
+		if err := os.WriteFile(s.path(entry.ID), data, 0600); err != nil {
+			return entry, fmt.Errorf("saving history entry: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	s.trim()
+	return entry, nil
+}
+
+// Get returns the entry with id
+func (s *Store) Get(id string) (domain.HistoryEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return domain.HistoryEntry{}, false
+}
+
+// List returns the stored entries, newest first
+func (s *Store) List() []domain.HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]domain.HistoryEntry, len(s.entries))
+	for i, entry := range s.entries {
+		entries[len(s.entries)-1-i] = entry
+	}
+	return entries
+}
+
+// trim drops the oldest entries beyond the maximum. The caller holds s.mu
+// or owns s.
+func (s *Store) trim() {
+	excess := len(s.entries) - s.maxEntries
+	if excess <= 0 {
+		return
+	}
+	if s.dir != "" {
+		for _, entry := range s.entries[:excess] {
+			os.Remove(s.path(entry.ID))
+		}
+	}
+	s.entries = append([]domain.HistoryEntry{}, s.entries[excess:]...)
+}
+
+// path returns the file an entry is saved in
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// clientLevels maps the levels of logging/setLevel to slog levels
+var clientLevels = map[sdk.LoggingLevel]slog.Level{
+	"debug":     sdk.LevelDebug,
+	"info":      sdk.LevelInfo,
+	"notice":    sdk.LevelNotice,
+	"warning":   sdk.LevelWarning,
+	"error":     sdk.LevelError,
+	"critical":  sdk.LevelCritical,
+	"alert":     sdk.LevelAlert,
+	"emergency": sdk.LevelEmergency,
+}
+
+// loggerName names the server in the notifications it sends
+const loggerName = "code-execution-mcp"
+
+// Forwarder sends log records as notifications/message to the sessions
+// that set a level with logging/setLevel. Records logged while handling a
+// request only go to the session that sent it.
+type Forwarder struct {
+	mu     sync.Mutex
+	server *sdk.Server
+	levels map[*sdk.ServerSession]slog.Level
+}
+
+func newForwarder() *Forwarder {
+	return &Forwarder{levels: make(map[*sdk.ServerSession]slog.Level)}
+}
+
+// Attach starts forwarding records to the clients of server
+func (f *Forwarder) Attach(server *sdk.Server) {
+	f.mu.Lock()
+	f.server = server
+	f.mu.Unlock()
+	server.AddReceivingMiddleware(f.trackLevels)
+}
+
+type sessionKey struct{}
+
+// WithSession returns a context whose records are only forwarded to ss
+func WithSession(ctx context.Context, ss *sdk.ServerSession) context.Context {
+	return context.WithValue(ctx, sessionKey{}, ss)
+}
+
+// trackLevels records the level each session asks for, and forgets the
+// sessions that have gone
+func (f *Forwarder) trackLevels(next sdk.MethodHandler) sdk.MethodHandler {
+	return func(ctx context.Context, method string, req sdk.Request) (sdk.Result, error) {
+		params, ok := req.GetParams().(*sdk.SetLoggingLevelParams)
+		ss, isServer := req.GetSession().(*sdk.ServerSession)
+		if method != "logging/setLevel" || !ok || !isServer {
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err == nil {
+			f.mu.Lock()
+			live := make(map[*sdk.ServerSession]bool)
+			for session := range f.server.Sessions() {
+				live[session] = true
+			}
+			for session := range f.levels {
+				if !live[session] {
+					delete(f.levels, session)
+				}
+			}
+			if level, known := clientLevels[params.Level]; known {
+				f.levels[ss] = level
+			}
+			f.mu.Unlock()
+		}
+		return result, err
+	}
+}
+
+// targets returns the sessions a record at level logged with ctx goes to
+func (f *Forwarder) targets(ctx context.Context, level slog.Level) []*sdk.ServerSession {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if only, ok := ctx.Value(sessionKey{}).(*sdk.ServerSession); ok {
+		if threshold, set := f.levels[only]; set && level >= threshold {
+			return []*sdk.ServerSession{only}
+		}
+		return nil
+	}
+	var sessions []*sdk.ServerSession
+	for ss, threshold := range f.levels {
+		if level >= threshold {
+			sessions = append(sessions, ss)
+		}
+	}
+	return sessions
+}
+
+// enabled reports whether any session wants a record at level
+func (f *Forwarder) enabled(ctx context.Context, level slog.Level) bool {
+	return len(f.targets(ctx, level)) > 0
+}
+
+// forward sends r to the sessions that want it, through the SDK's
+// handler with the logger's attributes and groups applied. Sending does
+// not wait for the request being handled to finish or fail.
+func (f *Forwarder) forward(ctx context.Context, r slog.Record, scope []func(slog.Handler) slog.Handler) {
+	for _, ss := range f.targets(ctx, r.Level) {
+		var h slog.Handler = sdk.NewLoggingHandler(ss, &sdk.LoggingHandlerOptions{LoggerName: loggerName})
+		for _, step := range scope {
+			h = step(h)
+		}
+		h.Handle(context.WithoutCancel(ctx), r.Clone())
+	}
+}
@@ -0,0 +1,87 @@
+// Package logging builds the server's slog logger. Records are written to
+// a local handler and, once a Forwarder is attached to the MCP server,
+// sent to the clients that asked for them with logging/setLevel.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// New returns a logger that writes records at or above cfg's level to w
+// in cfg's format, and the forwarder that also sends them to MCP clients.
+// w must not be the stdio transport's stdout.
+func New(cfg config.LoggingConfig, w io.Writer) (*slog.Logger, *Forwarder) {
+	var level slog.Level
+	// The level was checked when the config was loaded
+	level.UnmarshalText([]byte(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+
+	var local slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		local = slog.NewJSONHandler(w, opts)
+	} else {
+		local = slog.NewTextHandler(w, opts)
+	}
+
+	f := newForwarder()
+	return slog.New(&handler{local: local, forwarder: f}), f
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// handler writes records locally and forwards them to MCP clients. The
+// attributes and groups added to it are replayed on each client's
+// handler.
+type handler struct {
+	local     slog.Handler
+	forwarder *Forwarder
+	scope     []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.local.Enabled(ctx, level) || h.forwarder.enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.local.Enabled(ctx, r.Level) {
+		err = h.local.Handle(ctx, r)
+	}
+	h.forwarder.forward(ctx, r, h.scope)
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+// with returns a copy of h with step applied to its local handler and
+// recorded for the clients' handlers
+func (h *handler) with(step func(slog.Handler) slog.Handler) slog.Handler {
+	return &handler{
+		local:     step(h.local),
+		forwarder: h.forwarder,
+		scope:     append(h.scope[:len(h.scope):len(h.scope)], step),
+	}
+}
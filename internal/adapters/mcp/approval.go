@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/risk"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// approvalCodeLines is how much of each snippet the approval request shows
+const approvalCodeLines = 20
+
+// approvalSchema is the form sent with an approval request. It has no
+// fields: accepting the request approves the execution.
+var approvalSchema = map[string]any{
+	"type":       "object",
+	"properties": map[string]any{},
+}
+
+// approve classifies reqs and, when their risk reaches the configured
+// threshold, asks the client's user whether they may run. It returns the
+// result to send instead of running them, or nil and a note for the
+// result when they may run. label names the parts of a multi-request
+// tool, such as "stage" or "item".
+func (h *ToolHandler) approve(ctx context.Context, call *sdk.CallToolRequest, label string, reqs ...domain.ExecutionRequest) (*sdk.CallToolResult, string) {
+	cfg := h.cfg.Policy.Approval
+	if !cfg.Enabled {
+		return nil, ""
+	}
+	threshold, err := risk.ParseLevel(cfg.Threshold)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error checking approval: %v", err)), ""
+	}
+
+	rules := risk.Rules{LongTimeoutSeconds: cfg.LongTimeoutSeconds, WritableDirs: cfg.WritableDirs}
+	var assessment risk.Assessment
+	for i, req := range reqs {
+		for _, finding := range risk.Classify(req, rules).Findings {
+			if len(reqs) > 1 {
+				finding.Reason = fmt.Sprintf("%s %d, %s", label, i+1, findingText(finding))
+				finding.Line = 0
+			}
+			assessment.Add(finding)
+		}
+	}
+	if assessment.Level < threshold {
+		return nil, ""
+	}
+
+	approved, outcome := h.askApproval(ctx, call, approvalMessage(reqs, label, assessment))
+	if !approved {
+		var text strings.Builder
+		text.WriteString("## Execution Not Approved\n\n")
+		writeAssessment(&text, assessment)
+		text.WriteString(outcome + "\n")
+		return errorResult(text.String()), ""
+	}
+	return nil, fmt.Sprintf("**Approval:** %s risk. %s", assessment.Level, outcome)
+}
+
+// askApproval sends message to the client's user and reports whether they
+// approved, with a sentence describing how the decision was made
+func (h *ToolHandler) askApproval(ctx context.Context, call *sdk.CallToolRequest, message string) (bool, string) {
+	cfg := h.cfg.Policy.Approval
+	fallback := func(reason string) (bool, string) {
+		return cfg.Fallback == config.FallbackAllow, fmt.Sprintf("%s, so the configured default (%s) applied.", reason, cfg.Fallback)
+	}
+
+	var session *sdk.ServerSession
+	if call != nil {
+		session = call.Session
+	}
+	if session == nil {
+		return fallback("No client is connected to ask for approval")
+	}
+	if params := session.InitializeParams(); params == nil || params.Capabilities == nil || params.Capabilities.Elicitation == nil {
+		return fallback("The client does not support elicitation")
+	}
+
+	if cfg.WaitSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.WaitSeconds)*time.Second)
+		defer cancel()
+	}
+	result, err := session.Elicit(ctx, &sdk.ElicitParams{
+		Message:         message,
+		RequestedSchema: approvalSchema,
+	})
+	if err != nil {
+		return fallback(fmt.Sprintf("The approval request failed (%v)", err))
+	}
+
+	switch result.Action {
+	case "accept":
+		return true, "Approved by the user."
+	case "decline":
+		return false, "The user declined to run this code."
+	default:
+		return false, "The user dismissed the approval request."
+	}
+}
+
+// approvalMessage describes the flagged executions for the user
+func approvalMessage(reqs []domain.ExecutionRequest, label string, assessment risk.Assessment) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("The assistant wants to run code flagged as %s risk.\n\n", assessment.Level))
+	for _, finding := range assessment.Findings {
+		message.WriteString("- " + findingText(finding) + "\n")
+	}
+
+	for i, req := range reqs {
+		source := strings.Join([]string{req.Script, req.Code, req.TestCode}, "")
+		lines := strings.Split(strings.TrimRight(source, "\n"), "\n")
+		heading := LanguageLabel(req.Language)
+		if len(reqs) > 1 {
+			heading = fmt.Sprintf("%s %d (%s)", label, i+1, heading)
+		}
+		message.WriteString(fmt.Sprintf("\n%s:\n", heading))
+		for _, line := range lines[:min(len(lines), approvalCodeLines)] {
+			message.WriteString("    " + line + "\n")
+		}
+		if len(lines) > approvalCodeLines {
+			message.WriteString(fmt.Sprintf("    ... %d more lines\n", len(lines)-approvalCodeLines))
+		}
+	}
+
+	message.WriteString("\nAllow it to run?")
+	return message.String()
+}
+
+// writeAssessment lists the reasons an execution was flagged
+func writeAssessment(summary *strings.Builder, assessment risk.Assessment) {
+	summary.WriteString(fmt.Sprintf("**Risk:** %s\n\n", assessment.Level))
+	for _, finding := range assessment.Findings {
+		summary.WriteString("- " + findingText(finding) + "\n")
+	}
+	summary.WriteString("\n")
+}
+
+// findingText renders a finding with its line number, when it has one
+func findingText(finding risk.Finding) string {
+	if finding.Line > 0 {
+		return fmt.Sprintf("line %d: %s", finding.Line, finding.Reason)
+	}
+	return finding.Reason
+}
+
+// withNote puts note above the text of result
+func withNote(result *sdk.CallToolResult, note string) *sdk.CallToolResult {
+	if note == "" || len(result.Content) == 0 {
+		return result
+	}
+	if text, ok := result.Content[0].(*sdk.TextContent); ok {
+		text.Text = note + "\n\n" + text.Text
+	}
+	return result
+}
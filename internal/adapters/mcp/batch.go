@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// BatchItem represents one snippet in a batch execution
+type BatchItem struct {
+	Language string   `json:"language"`
+	Code     string   `json:"code"`
+	Args     []string `json:"args,omitempty"`
+	Stdin    string   `json:"stdin,omitempty"`
+	Timeout  int      `json:"timeout,omitempty"`
+}
+
+// BatchInput represents input for concurrent batch execution
+type BatchInput struct {
+	Items       []BatchItem `json:"items"`
+	Concurrency int         `json:"concurrency,omitempty"`
+	FailFast    bool        `json:"fail_fast,omitempty"`
+}
+
+// Batch item statuses shown in the summary table
+const (
+	batchOK        = "ok"
+	batchFailed    = "failed"
+	batchTimeout   = "timeout"
+	batchCancelled = "cancelled"
+	batchSkipped   = "skipped"
+	batchError     = "error"
+)
+
+// batchOutcome holds the result of a single batch item
+type batchOutcome struct {
+	status string
+	result *domain.ExecutionResult
+	err    error
+}
+
+// executeBatch handles concurrent execution of several snippets
+func (h *ToolHandler) executeBatch(ctx context.Context, call *sdk.CallToolRequest, input BatchInput) (*sdk.CallToolResult, any, error) {
+	if len(input.Items) == 0 {
+		return errorResult("Batch must contain at least one item"), nil, nil
+	}
+	if limit := h.cfg.Batch.MaxItems; limit > 0 && len(input.Items) > limit {
+		return errorResult(fmt.Sprintf("Batch has %d items; the maximum is %d", len(input.Items), limit)), nil, nil
+	}
+
+	concurrency := h.cfg.Batch.MaxConcurrency
+	if input.Concurrency > 0 && (concurrency <= 0 || input.Concurrency < concurrency) {
+		concurrency = input.Concurrency
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	reqs := make([]domain.ExecutionRequest, len(input.Items))
+	for i, item := range input.Items {
+		reqs[i] = newRequest(item.Language, item.Code, item.Args, item.Stdin, "", item.Timeout)
+	}
+	denied, note := h.approve(ctx, call, "item", reqs...)
+	if denied != nil {
+		return denied, nil, nil
+	}
+
+	startTime := time.Now()
+	outcomes := h.runBatch(ctx, call, input.Items, concurrency, input.FailFast)
+	return withNote(formatBatchResult(input.Items, outcomes, time.Since(startTime)), note), nil, nil
+}
+
+// runBatch executes the items with at most concurrency running at once.
+// With failFast, the first failure cancels running items and skips the
+// ones that have not started.
+func (h *ToolHandler) runBatch(ctx context.Context, call *sdk.CallToolRequest, items []BatchItem, concurrency int, failFast bool) []batchOutcome {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make([]batchOutcome, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	failed := -1
+
+	for i, item := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			outcomes[i] = batchOutcome{status: batchSkipped}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item BatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			outcome := h.runBatchItem(ctx, call, item)
+			if outcome.status != batchOK && failFast {
+				failOnce.Do(func() {
+					failed = i
+					cancel()
+				})
+			}
+			outcomes[i] = outcome
+		}(i, item)
+	}
+	wg.Wait()
+
+	// Items killed by the fail-fast cancellation did not fail on their own
+	if failed >= 0 {
+		for i := range outcomes {
+			if i != failed && outcomes[i].status == batchFailed && outcomes[i].result.ExitCode == -1 {
+				outcomes[i].status = batchCancelled
+			}
+		}
+	}
+	return outcomes
+}
+
+// runBatchItem executes one item and classifies its outcome
+func (h *ToolHandler) runBatchItem(ctx context.Context, call *sdk.CallToolRequest, item BatchItem) batchOutcome {
+	if ctx.Err() != nil {
+		return batchOutcome{status: batchSkipped}
+	}
+
+	executor, ok := h.executorFor(item.Language)
+	if !ok {
+		return batchOutcome{status: batchError, err: fmt.Errorf("unsupported language %q", item.Language)}
+	}
+
+	req := newRequest(item.Language, item.Code, item.Args, item.Stdin, "", item.Timeout)
+	tagRequest(&req, call)
+	result, err := executor.Execute(ctx, req)
+	h.redact(result)
+	switch {
+	case err != nil:
+		return batchOutcome{status: batchError, err: err}
+	case result.ErrorType == domain.TimeoutError:
+		return batchOutcome{status: batchTimeout, result: result}
+	case result.IsError:
+		return batchOutcome{status: batchFailed, result: result}
+	default:
+		return batchOutcome{status: batchOK, result: result}
+	}
+}
+
+// formatBatchResult renders the summary table followed by each item's output
+func formatBatchResult(items []BatchItem, outcomes []batchOutcome, duration time.Duration) *sdk.CallToolResult {
+	succeeded := 0
+	for _, outcome := range outcomes {
+		if outcome.status == batchOK {
+			succeeded++
+		}
+	}
+
+	var summary strings.Builder
+	summary.WriteString("## Batch Execution Result\n\n")
+	summary.WriteString(fmt.Sprintf("**Items:** %d (%d succeeded, %d did not)\n", len(items), succeeded, len(items)-succeeded))
+	summary.WriteString(fmt.Sprintf("**Duration:** %s\n\n", duration.String()))
+
+	summary.WriteString("| # | Language | Status | Exit Code | Duration |\n")
+	summary.WriteString("|---|----------|--------|-----------|----------|\n")
+	for i, outcome := range outcomes {
+		exitCode, itemDuration := "-", "-"
+		if outcome.result != nil {
+			exitCode = fmt.Sprintf("%d", outcome.result.ExitCode)
+			itemDuration = outcome.result.Duration.String()
+		}
+		summary.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n",
+			i+1, LanguageLabel(items[i].Language), outcome.status, exitCode, itemDuration))
+	}
+	summary.WriteString("\n")
+
+	for i, outcome := range outcomes {
+		summary.WriteString(fmt.Sprintf("### Item %d: %s (%s)\n\n", i+1, LanguageLabel(items[i].Language), outcome.status))
+		switch {
+		case outcome.err != nil:
+			summary.WriteString(fmt.Sprintf("Error: %v\n\n", outcome.err))
+		case outcome.result != nil:
+			writeResultDetails(&summary, outcome.result, "####")
+			if !strings.HasSuffix(summary.String(), "\n\n") {
+				summary.WriteString("\n")
+			}
+		default:
+			summary.WriteString("Not run.\n\n")
+		}
+	}
+
+	return &sdk.CallToolResult{
+		IsError: succeeded < len(items),
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: summary.String()},
+		},
+	}
+}
+
+// errorResult wraps a message in an error tool result
+func errorResult(message string) *sdk.CallToolResult {
+	return &sdk.CallToolResult{
+		IsError: true,
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: message},
+		},
+	}
+}
@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/stats"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// BenchmarkInput represents input for benchmarking code. Setting
+// CompareCode benchmarks a second snippet in the same language, with the
+// runs of both interleaved so that drift in machine load affects them
+// equally.
+type BenchmarkInput struct {
+	Language    string   `json:"language"`
+	Code        string   `json:"code"`
+	CompareCode string   `json:"compare_code,omitempty"`
+	Args        []string `json:"args,omitempty"`
+	Stdin       string   `json:"stdin,omitempty"`
+	Runs        int      `json:"runs,omitempty"`
+	Warmup      int      `json:"warmup,omitempty"`
+	TimeBudget  int      `json:"time_budget,omitempty"`
+	Timeout     int      `json:"timeout,omitempty"`
+}
+
+// Benchmark defaults when the input leaves them unset
+const (
+	defaultBenchmarkRuns   = 10
+	defaultBenchmarkWarmup = 1
+	significanceLevel      = 0.05
+)
+
+// benchmarkSeries collects the measurements for one snippet
+type benchmarkSeries struct {
+	label       string
+	compileTime time.Duration
+	buildCache  domain.CacheStatus
+	samples     []float64
+	cpu         []float64
+	peakKB      int64
+}
+
+// benchmarkCode handles repeated timing of one or two snippets
+func (h *ToolHandler) benchmarkCode(ctx context.Context, call *sdk.CallToolRequest, input BenchmarkInput) (*sdk.CallToolResult, any, error) {
+	executor, ok := h.executorFor(input.Language)
+	if !ok {
+		return errorResult(fmt.Sprintf("Unsupported language %q", input.Language)), nil, nil
+	}
+
+	runs := input.Runs
+	if runs <= 0 {
+		runs = defaultBenchmarkRuns
+	}
+	if limit := h.cfg.Benchmark.MaxRuns; limit > 0 && runs > limit {
+		runs = limit
+	}
+	warmup := input.Warmup
+	if warmup <= 0 {
+		warmup = defaultBenchmarkWarmup
+	}
+	if limit := h.cfg.Benchmark.MaxRuns; limit > 0 && warmup > limit {
+		warmup = limit
+	}
+	budget := time.Duration(input.TimeBudget) * time.Second
+	if limit := time.Duration(h.cfg.Benchmark.MaxTimeBudgetSeconds) * time.Second; limit > 0 && (budget <= 0 || budget > limit) {
+		budget = limit
+	}
+
+	codes := []string{input.Code}
+	series := []*benchmarkSeries{{label: "A"}}
+	if input.CompareCode != "" {
+		codes = append(codes, input.CompareCode)
+		series = append(series, &benchmarkSeries{label: "B"})
+	}
+
+	reqs := make([]domain.ExecutionRequest, len(codes))
+	for i, code := range codes {
+		reqs[i] = newRequest(input.Language, code, input.Args, input.Stdin, "", input.Timeout)
+	}
+	denied, note := h.approve(ctx, call, "snippet", reqs...)
+	if denied != nil {
+		return denied, nil, nil
+	}
+
+	run := func(i int) (*domain.ExecutionResult, error) {
+		req := newRequest(input.Language, codes[i], input.Args, input.Stdin, "", input.Timeout)
+		tagRequest(&req, call)
+		result, err := executor.Execute(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsError {
+			h.redact(result)
+			return result, fmt.Errorf("snippet %s failed", series[i].label)
+		}
+		return result, nil
+	}
+
+	// Warm-up runs also absorb the compile step for Go, which is reported
+	// separately from the run-time samples. They count against the time
+	// budget, but the first always runs, and so does the first sample.
+	startTime := time.Now()
+	overBudget := func() bool {
+		return budget > 0 && time.Since(startTime) > budget
+	}
+	for i := range codes {
+		for w := 0; w < warmup; w++ {
+			if w > 0 && overBudget() {
+				break
+			}
+			result, err := run(i)
+			if err != nil {
+				return withNote(benchmarkFailure(input.Language, result, err), note), nil, nil
+			}
+			if w == 0 {
+				series[i].compileTime = result.CompileDuration
+				series[i].buildCache = result.BuildCache
+			}
+		}
+	}
+
+	for len(series[0].samples) < runs {
+		if len(series[0].samples) > 0 && overBudget() {
+			break
+		}
+		for i := range codes {
+			result, err := run(i)
+			if err != nil {
+				return withNote(benchmarkFailure(input.Language, result, err), note), nil, nil
+			}
+			series[i].record(result)
+		}
+	}
+
+	return withNote(formatBenchmark(input.Language, series, time.Since(startTime)), note), nil, nil
+}
+
+// record adds one run's measurements. For compiled languages only the run
+// time is sampled, so that a cache miss doesn't skew the numbers.
+func (s *benchmarkSeries) record(result *domain.ExecutionResult) {
+	sample := result.Duration
+	if result.CompileDuration > 0 {
+		sample = result.RunDuration
+	}
+	s.samples = append(s.samples, float64(sample))
+	s.cpu = append(s.cpu, float64(result.CPUTime))
+	s.peakKB = max(s.peakKB, result.PeakMemoryKB)
+}
+
+// benchmarkFailure reports a run that failed, along with its output
+func benchmarkFailure(language string, result *domain.ExecutionResult, err error) *sdk.CallToolResult {
+	if result == nil {
+		return errorResult(fmt.Sprintf("Error benchmarking %s code: %v", LanguageLabel(language), err))
+	}
+	failure := FormatResult(result, LanguageLabel(language))
+	text := failure.Content[0].(*sdk.TextContent)
+	text.Text = fmt.Sprintf("Benchmark aborted: %v\n\n%s", err, text.Text)
+	return failure
+}
+
+// formatBenchmark renders the statistics table and, in comparative mode,
+// the speedup with its significance
+func formatBenchmark(language string, series []*benchmarkSeries, elapsed time.Duration) *sdk.CallToolResult {
+	summaries := make([]stats.Summary, len(series))
+	for i, s := range series {
+		summaries[i] = stats.Summarize(s.samples)
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("## %s Benchmark Result\n\n", LanguageLabel(language)))
+	summary.WriteString(fmt.Sprintf("**Runs:** %d per snippet\n", summaries[0].N))
+	summary.WriteString(fmt.Sprintf("**Wall Time:** %s\n\n", elapsed.Round(time.Millisecond)))
+
+	header := "| Metric |"
+	divider := "|--------|"
+	for _, s := range series {
+		header += fmt.Sprintf(" %s |", s.label)
+		divider += "---|"
+	}
+	summary.WriteString(header + "\n" + divider + "\n")
+
+	row := func(name string, value func(i int) string) {
+		summary.WriteString("| " + name + " |")
+		for i := range series {
+			summary.WriteString(" " + value(i) + " |")
+		}
+		summary.WriteString("\n")
+	}
+	duration := func(ns float64) string {
+		return formatDuration(time.Duration(ns))
+	}
+	row("Min", func(i int) string { return duration(summaries[i].Min) })
+	row("Median", func(i int) string { return duration(summaries[i].Median) })
+	row("Mean", func(i int) string { return duration(summaries[i].Mean) })
+	row("P95", func(i int) string { return duration(summaries[i].P95) })
+	row("Max", func(i int) string { return duration(summaries[i].Max) })
+	row("Stddev", func(i int) string { return duration(summaries[i].Stddev) })
+	row("CPU Time (mean)", func(i int) string { return duration(stats.Summarize(series[i].cpu).Mean) })
+	row("Peak Memory", func(i int) string {
+		if series[i].peakKB == 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.1f MiB", float64(series[i].peakKB)/1024)
+	})
+	if series[0].compileTime > 0 {
+		row("Compile Time", func(i int) string {
+			return fmt.Sprintf("%s (cache %s)", formatDuration(series[i].compileTime), series[i].buildCache)
+		})
+	}
+	summary.WriteString("\n")
+
+	if len(series) == 2 {
+		writeComparison(&summary, series, summaries)
+	}
+
+	return &sdk.CallToolResult{
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: summary.String()},
+		},
+	}
+}
+
+// writeComparison reports the relative speed of B against A
+func writeComparison(summary *strings.Builder, series []*benchmarkSeries, summaries []stats.Summary) {
+	a, b := summaries[0].Median, summaries[1].Median
+	_, _, p := stats.WelchTTest(series[0].samples, series[1].samples)
+
+	summary.WriteString("### Comparison\n\n")
+	switch {
+	case a == 0 || b == 0:
+		summary.WriteString("Not enough timing resolution to compare.\n")
+		return
+	case b < a:
+		summary.WriteString(fmt.Sprintf("**B is %.2fx faster than A** (median %s vs %s)\n", a/b, formatDuration(time.Duration(b)), formatDuration(time.Duration(a))))
+	default:
+		summary.WriteString(fmt.Sprintf("**B is %.2fx slower than A** (median %s vs %s)\n", b/a, formatDuration(time.Duration(b)), formatDuration(time.Duration(a))))
+	}
+
+	verdict := "not statistically significant"
+	if p < significanceLevel {
+		verdict = "statistically significant"
+	}
+	summary.WriteString(fmt.Sprintf("**Significance:** p = %.4f (Welch's t-test), %s at the %.0f%% level\n", p, verdict, significanceLevel*100))
+}
+
+// formatDuration rounds a duration to a readable precision
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(100 * time.Nanosecond).String()
+	}
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// GetCapabilitiesInput represents input for reporting toolchains and
+// sandbox capabilities
+type GetCapabilitiesInput struct{}
+
+// getCapabilities handles reporting the toolchains and confinement
+// features the server found at startup
+func (h *ToolHandler) getCapabilities(ctx context.Context, _ *sdk.CallToolRequest, _ GetCapabilitiesInput) (*sdk.CallToolResult, any, error) {
+	var text strings.Builder
+	text.WriteString("## Capabilities\n\n")
+	text.WriteString(fmt.Sprintf("**Platform:** %s/%s\n\n", runtime.GOOS, runtime.GOARCH))
+	if h.toolchains != nil {
+		writeToolchains(&text, h.toolchains.Toolchains())
+	}
+	if h.capabilities != nil {
+		text.WriteString("### Sandbox\n\n")
+		text.WriteString("| Feature | Available | Enabled | Detail |\n")
+		text.WriteString("|---------|-----------|---------|--------|\n")
+		for _, c := range h.capabilities.Capabilities() {
+			text.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", c.Name, yesNo(c.Available), yesNo(c.Enabled), c.Detail))
+		}
+	}
+
+	return &sdk.CallToolResult{
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: text.String()},
+		},
+	}, nil, nil
+}
+
+// writeToolchains renders the toolchains, then the Python packages and the
+// Go environment
+func writeToolchains(text *strings.Builder, toolchains []domain.Toolchain) {
+	text.WriteString("### Toolchains\n\n")
+	text.WriteString("| Language | Program | Available | Version | Path | Detail |\n")
+	text.WriteString("|----------|---------|-----------|---------|------|--------|\n")
+	for _, t := range toolchains {
+		text.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n", t.Language, t.Name, yesNo(t.Available), t.Version, t.Path, t.Detail))
+	}
+	text.WriteString("\n")
+
+	for _, t := range toolchains {
+		if len(t.Packages) > 0 {
+			text.WriteString(fmt.Sprintf("#### %s Packages\n\n", LanguageLabel(t.Language)))
+			for _, name := range slices.Sorted(maps.Keys(t.Packages)) {
+				version := t.Packages[name]
+				if version == "" {
+					version = "not installed"
+				}
+				text.WriteString(fmt.Sprintf("- %s: %s\n", name, version))
+			}
+			text.WriteString("\n")
+		}
+		if len(t.Env) > 0 {
+			text.WriteString(fmt.Sprintf("#### %s Environment\n\n", LanguageLabel(t.Language)))
+			for _, name := range slices.Sorted(maps.Keys(t.Env)) {
+				text.WriteString(fmt.Sprintf("- %s=%s\n", name, t.Env[name]))
+			}
+			text.WriteString("\n")
+		}
+	}
+}
+
+// yesNo renders a boolean for a table cell
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
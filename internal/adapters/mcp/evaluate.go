@@ -0,0 +1,194 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/judge"
+	"github.com/aravi/code_execution_mcp/internal/core/textdiff"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestCase represents one input/expected-output pair for evaluate_code
+type TestCase struct {
+	Name           string   `json:"name,omitempty"`
+	Stdin          string   `json:"stdin,omitempty"`
+	Args           []string `json:"args,omitempty"`
+	ExpectedStdout string   `json:"expected_stdout"`
+	Comparator     string   `json:"comparator,omitempty"`
+	Tolerance      float64  `json:"tolerance,omitempty"`
+	Timeout        int      `json:"timeout,omitempty"`
+}
+
+// EvaluateInput represents input for judging code against test cases
+type EvaluateInput struct {
+	Language  string     `json:"language"`
+	Code      string     `json:"code"`
+	TestCases []TestCase `json:"test_cases"`
+	Timeout   int        `json:"timeout,omitempty"`
+}
+
+// Test case verdicts
+const (
+	verdictPassed       = "passed"
+	verdictWrongAnswer  = "wrong answer"
+	verdictTimeout      = "time limit exceeded"
+	verdictRuntimeError = "runtime error"
+	verdictError        = "error"
+)
+
+// caseOutcome holds the verdict for a single test case
+type caseOutcome struct {
+	verdict string
+	result  *domain.ExecutionResult
+	diff    string
+	detail  string
+}
+
+// evaluateCode handles running code against test cases
+func (h *ToolHandler) evaluateCode(ctx context.Context, call *sdk.CallToolRequest, input EvaluateInput) (*sdk.CallToolResult, any, error) {
+	if len(input.TestCases) == 0 {
+		return errorResult("At least one test case is required"), nil, nil
+	}
+
+	executor, ok := h.executorFor(input.Language)
+	if !ok {
+		return errorResult(fmt.Sprintf("Unsupported language %q", input.Language)), nil, nil
+	}
+
+	// The program is the same for every case, so it is approved once with
+	// the longest time limit any case asks for
+	longest := input.Timeout
+	for _, tc := range input.TestCases {
+		longest = max(longest, tc.Timeout)
+	}
+	denied, note := h.approve(ctx, call, "", newRequest(input.Language, input.Code, nil, "", "", longest))
+	if denied != nil {
+		return denied, nil, nil
+	}
+
+	outcomes := make([]caseOutcome, 0, len(input.TestCases))
+	for _, tc := range input.TestCases {
+		timeout := tc.Timeout
+		if timeout <= 0 {
+			timeout = input.Timeout
+		}
+		req := newRequest(input.Language, input.Code, tc.Args, tc.Stdin, "", timeout)
+		tagRequest(&req, call)
+
+		result, err := executor.Execute(ctx, req)
+		if err != nil {
+			outcomes = append(outcomes, caseOutcome{verdict: verdictError, detail: err.Error()})
+			continue
+		}
+
+		// A rejected or uncompilable program fails every case the same way
+		if result.ErrorType == domain.ValidationError || result.ErrorType == domain.CompilationError {
+			return withNote(h.present(ctx, result, LanguageLabel(input.Language)), note), nil, nil
+		}
+
+		// Cases are judged on the real output; only what is shown is masked
+		outcome := judgeCase(tc, result)
+		h.redact(result)
+		outcome.diff = h.redactText(outcome.diff)
+		outcomes = append(outcomes, outcome)
+	}
+
+	return withNote(formatEvaluation(input, outcomes), note), nil, nil
+}
+
+// judgeCase compares a test case's output with the expected output
+func judgeCase(tc TestCase, result *domain.ExecutionResult) caseOutcome {
+	switch {
+	case result.ErrorType == domain.TimeoutError:
+		return caseOutcome{verdict: verdictTimeout, result: result}
+	case result.ErrorType == domain.SyscallBlockedError:
+		return caseOutcome{verdict: verdictRuntimeError, result: result, detail: "blocked system call: " + strings.Join(result.BlockedSyscalls, ", ")}
+	case result.IsError:
+		return caseOutcome{verdict: verdictRuntimeError, result: result, detail: fmt.Sprintf("exit code %d", result.ExitCode)}
+	}
+
+	ok, err := judge.Compare(tc.Comparator, tc.ExpectedStdout, result.Stdout, tc.Tolerance)
+	if err != nil {
+		return caseOutcome{verdict: verdictError, result: result, detail: err.Error()}
+	}
+	if ok {
+		return caseOutcome{verdict: verdictPassed, result: result}
+	}
+
+	outcome := caseOutcome{verdict: verdictWrongAnswer, result: result}
+	if tc.Comparator == judge.Regex {
+		outcome.detail = fmt.Sprintf("output does not match /%s/", tc.ExpectedStdout)
+	}
+	outcome.diff = textdiff.Unified(tc.ExpectedStdout, result.Stdout, "expected", "actual")
+	return outcome
+}
+
+// formatEvaluation renders the score, a per-case table and failure details
+func formatEvaluation(input EvaluateInput, outcomes []caseOutcome) *sdk.CallToolResult {
+	passed := 0
+	for _, outcome := range outcomes {
+		if outcome.verdict == verdictPassed {
+			passed++
+		}
+	}
+	total := len(outcomes)
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("## %s Evaluation Result\n\n", LanguageLabel(input.Language)))
+	summary.WriteString(fmt.Sprintf("**Score:** %d/%d passed (%.0f%%)\n\n", passed, total, 100*float64(passed)/float64(total)))
+
+	summary.WriteString("| # | Case | Verdict | Comparator | Duration |\n")
+	summary.WriteString("|---|------|---------|------------|----------|\n")
+	for i, outcome := range outcomes {
+		tc := input.TestCases[i]
+		comparator := tc.Comparator
+		if comparator == "" {
+			comparator = judge.Exact
+		}
+		duration := "-"
+		if outcome.result != nil {
+			duration = outcome.result.Duration.String()
+		}
+		summary.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n", i+1, caseName(tc, i), outcome.verdict, comparator, duration))
+	}
+	summary.WriteString("\n")
+
+	for i, outcome := range outcomes {
+		if outcome.verdict == verdictPassed {
+			continue
+		}
+		summary.WriteString(fmt.Sprintf("### Case %d: %s (%s)\n\n", i+1, caseName(input.TestCases[i], i), outcome.verdict))
+		if outcome.detail != "" {
+			summary.WriteString(outcome.detail + "\n\n")
+		}
+		if outcome.diff != "" {
+			summary.WriteString("```diff\n" + outcome.diff + "```\n\n")
+		}
+		if outcome.result != nil && outcome.result.Stderr != "" {
+			summary.WriteString("#### Standard Error\n```\n")
+			summary.WriteString(outcome.result.Stderr)
+			if !strings.HasSuffix(outcome.result.Stderr, "\n") {
+				summary.WriteString("\n")
+			}
+			summary.WriteString("```\n\n")
+		}
+	}
+
+	return &sdk.CallToolResult{
+		IsError: passed < total,
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: summary.String()},
+		},
+	}
+}
+
+// caseName returns the test case's name or a positional default
+func caseName(tc TestCase, index int) string {
+	if tc.Name != "" {
+		return tc.Name
+	}
+	return fmt.Sprintf("case %d", index+1)
+}
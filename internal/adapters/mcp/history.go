@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/logging"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// historyURI is the resource listing past executions; each has its own
+// resource below it
+const historyURI = "exec://history"
+
+// RerunInput represents input for re-running a past execution. Unset
+// fields keep the values the execution ran with.
+type RerunInput struct {
+	ID         string   `json:"id"`
+	Code       *string  `json:"code,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	Stdin      *string  `json:"stdin,omitempty"`
+	WorkingDir *string  `json:"working_dir,omitempty"`
+	Timeout    int      `json:"timeout,omitempty"`
+	Network    string   `json:"network,omitempty"`
+}
+
+// historySummary describes a past execution in the history listing
+type historySummary struct {
+	ID         string    `json:"id"`
+	URI        string    `json:"uri"`
+	Time       time.Time `json:"time"`
+	Tool       string    `json:"tool,omitempty"`
+	Language   string    `json:"language"`
+	RerunOf    string    `json:"rerun_of,omitempty"`
+	ExitCode   int       `json:"exit_code"`
+	IsError    bool      `json:"is_error"`
+	ErrorType  string    `json:"error_type,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// historyDetail is a past execution with its request and full output
+type historyDetail struct {
+	historySummary
+	Request    historyRequest `json:"request"`
+	Stdout     string         `json:"stdout"`
+	Stderr     string         `json:"stderr"`
+	Violations []string       `json:"violations,omitempty"`
+	Artifacts  []string       `json:"artifacts,omitempty"`
+}
+
+// historyRequest holds the parameters an execution ran with
+type historyRequest struct {
+	Code       string   `json:"code"`
+	Args       []string `json:"args,omitempty"`
+	Stdin      string   `json:"stdin,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+	Timeout    int      `json:"timeout,omitempty"`
+	Mode       string   `json:"mode,omitempty"`
+	TestCode   string   `json:"test_code,omitempty"`
+	Profile    bool     `json:"profile,omitempty"`
+	Coverage   bool     `json:"coverage,omitempty"`
+	Network    string   `json:"network,omitempty"`
+}
+
+// RegisterResources registers the execution history resources with the
+// MCP server, when the history is enabled
+func (h *ToolHandler) RegisterResources(server *sdk.Server) {
+	if h.history == nil {
+		return
+	}
+
+	description := "The executions this session ran, newest first, with their exit status and the URI of each."
+	if h.cfg.History.Shared {
+		description = "The executions the server ran, newest first, with their exit status and the URI of each."
+	}
+	server.AddResource(&sdk.Resource{
+		URI:         historyURI,
+		Name:        "execution_history",
+		Description: description,
+		MIMEType:    "application/json",
+	}, h.readHistory)
+
+	server.AddResourceTemplate(&sdk.ResourceTemplate{
+		URITemplate: historyURI + "/{id}",
+		Name:        "execution",
+		Description: "A past execution: its code, the parameters it ran with and its full output.",
+		MIMEType:    "application/json",
+	}, h.readExecution)
+}
+
+// readHistory lists the caller's past executions
+func (h *ToolHandler) readHistory(_ context.Context, req *sdk.ReadResourceRequest) (*sdk.ReadResourceResult, error) {
+	session := resourceSession(req)
+	summaries := []historySummary{}
+	for _, entry := range h.history.List() {
+		if h.visible(entry, session) {
+			summaries = append(summaries, summarize(entry))
+		}
+	}
+	return jsonResource(req.Params.URI, summaries)
+}
+
+// readExecution returns one of the caller's past executions
+func (h *ToolHandler) readExecution(_ context.Context, req *sdk.ReadResourceRequest) (*sdk.ReadResourceResult, error) {
+	id, ok := strings.CutPrefix(req.Params.URI, historyURI+"/")
+	entry, found := h.history.Get(id)
+	if !ok || !found || !h.visible(entry, resourceSession(req)) {
+		return nil, sdk.ResourceNotFoundError(req.Params.URI)
+	}
+
+	code := entry.Request.Code
+	if code == "" {
+		code = entry.Request.Script
+	}
+	detail := historyDetail{
+		historySummary: summarize(entry),
+		Request: historyRequest{
+			Code:       code,
+			Args:       entry.Request.Args,
+			Stdin:      entry.Request.Stdin,
+			WorkingDir: entry.Request.WorkingDir,
+			Timeout:    entry.Request.Timeout,
+			Mode:       entry.Request.Mode,
+			TestCode:   entry.Request.TestCode,
+			Profile:    entry.Request.Profile,
+			Coverage:   entry.Request.Coverage,
+			Network:    entry.Request.Network,
+		},
+		Stdout: entry.Result.Stdout,
+		Stderr: entry.Result.Stderr,
+	}
+	for _, v := range entry.Result.Violations {
+		detail.Violations = append(detail.Violations, v.Rule)
+	}
+	for _, artifact := range entry.Result.Artifacts {
+		detail.Artifacts = append(detail.Artifacts, artifact.Name)
+	}
+	return jsonResource(req.Params.URI, detail)
+}
+
+// resourceSession returns the ID of the session reading a resource
+func resourceSession(req *sdk.ReadResourceRequest) string {
+	if req.Session == nil {
+		return ""
+	}
+	return req.Session.ID()
+}
+
+// visible reports whether the session may read and re-run entry: its own
+// executions, or every execution when the history is shared
+func (h *ToolHandler) visible(entry domain.HistoryEntry, session string) bool {
+	return h.cfg.History.Shared || entry.Request.Session == session
+}
+
+// summarize describes entry for the history listing
+func summarize(entry domain.HistoryEntry) historySummary {
+	summary := historySummary{
+		ID:         entry.ID,
+		URI:        historyURI + "/" + entry.ID,
+		Time:       entry.Time,
+		Tool:       entry.Request.Tool,
+		Language:   entry.Request.Language,
+		RerunOf:    entry.RerunOf,
+		ExitCode:   entry.Result.ExitCode,
+		IsError:    entry.Result.IsError,
+		DurationMS: entry.Result.Duration.Milliseconds(),
+	}
+	if entry.Result.IsError {
+		summary.ErrorType = entry.Result.ErrorType.String()
+	}
+	return summary
+}
+
+// jsonResource renders v as the JSON contents of the resource at uri
+func jsonResource(uri string, v any) (*sdk.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.ReadResourceResult{
+		Contents: []*sdk.ResourceContents{
+			{URI: uri, MIMEType: "application/json", Text: string(data)},
+		},
+	}, nil
+}
+
+// remember records an execution in the history and notes its URI in the
+// response. rerunOf names the entry it re-ran, if any.
+func (h *ToolHandler) remember(ctx context.Context, response *sdk.CallToolResult, req domain.ExecutionRequest, result *domain.ExecutionResult, rerunOf string) *sdk.CallToolResult {
+	if h.history == nil {
+		return response
+	}
+	entry, err := h.history.Record(domain.HistoryEntry{Request: req, Result: result, RerunOf: rerunOf})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Recording execution history failed", "error", err)
+		return response
+	}
+	if text, ok := response.Content[0].(*sdk.TextContent); ok {
+		text.Text = strings.TrimRight(text.Text, "\n") + fmt.Sprintf("\n\n**History:** `%s/%s` (id `%s`)\n", historyURI, entry.ID, entry.ID)
+	}
+	return response
+}
+
+// rerunExecution handles running a past execution again, with the
+// requested overrides
+func (h *ToolHandler) rerunExecution(ctx context.Context, call *sdk.CallToolRequest, input RerunInput) (*sdk.CallToolResult, any, error) {
+	entry, found := h.history.Get(input.ID)
+	session := ""
+	if call.Session != nil {
+		session = call.Session.ID()
+	}
+	if !found || !h.visible(entry, session) {
+		return errorResult(fmt.Sprintf("No execution %q in the history; read %s for the available IDs", input.ID, historyURI)), nil, nil
+	}
+
+	req := entry.Request
+	if input.Code != nil {
+		if req.Script != "" {
+			req.Script = *input.Code
+		} else {
+			req.Code = *input.Code
+		}
+	}
+	if input.Args != nil {
+		req.Args = input.Args
+	}
+	if input.Stdin != nil {
+		req.Stdin = *input.Stdin
+	}
+	if input.WorkingDir != nil {
+		req.WorkingDir = *input.WorkingDir
+	}
+	if input.Timeout > 0 {
+		req.Timeout = input.Timeout
+	}
+	if input.Network != "" {
+		req.Network = input.Network
+	}
+	tagRequest(&req, call)
+
+	executor, ok := h.executorFor(req.Language)
+	if !ok {
+		return errorResult(fmt.Sprintf("Unsupported language %q", req.Language)), nil, nil
+	}
+	denied, note := h.approve(ctx, call, "", req)
+	if denied != nil {
+		return denied, nil, nil
+	}
+
+	logging.FromContext(ctx).Info("Re-running execution", "history_id", entry.ID)
+	result, err := executor.Execute(ctx, req)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error re-running execution %s: %v", entry.ID, err)), nil, nil
+	}
+
+	response := h.present(ctx, result, LanguageLabel(req.Language))
+	return withNote(h.remember(ctx, response, req, result, entry.ID), note), nil, nil
+}
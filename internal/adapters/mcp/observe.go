@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/aravi/code_execution_mcp/internal/adapters/logging"
+	"github.com/aravi/code_execution_mcp/internal/adapters/tracing"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// auditLog appends a JSON line for each tool call to a file
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// auditEntry is one line of the audit log
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	Session    string    `json:"session,omitempty"`
+	Tool       string    `json:"tool"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	IsError    bool      `json:"is_error"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// openAuditLog opens the audit log at path, or returns nil when path is
+// empty or the file cannot be opened
+func openAuditLog(path string) *auditLog {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		slog.Warn("Audit log disabled", "error", err)
+		return nil
+	}
+	return &auditLog{file: f}
+}
+
+// record appends entry to the log
+func (a *auditLog) record(entry auditEntry) {
+	if a == nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		slog.Error("Writing audit log failed", "error", err)
+	}
+}
+
+// newRequestID returns a random ID for a tool call
+func newRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// observeToolCalls traces each tool call as a server span, continuing the
+// trace named by a traceparent in the request's _meta, gives it a logger
+// tagged with a new request ID, and records it in the audit log
+func (h *ToolHandler) observeToolCalls(next sdk.MethodHandler) sdk.MethodHandler {
+	return func(ctx context.Context, method string, req sdk.Request) (sdk.Result, error) {
+		call, ok := req.(*sdk.CallToolRequest)
+		if method != "tools/call" || !ok || call.Params == nil {
+			return next(ctx, method, req)
+		}
+
+		if traceparent, ok := call.Params.Meta["traceparent"].(string); ok {
+			ctx = tracing.WithRemoteParent(ctx, traceparent)
+		}
+		started := time.Now()
+		ctx, span := tracing.StartServer(ctx, "tools/call "+call.Params.Name, tracing.String("mcp.tool", call.Params.Name))
+		defer span.End()
+
+		entry := auditEntry{
+			Time:      started.UTC(),
+			RequestID: newRequestID(),
+			Tool:      call.Params.Name,
+			TraceID:   tracing.TraceID(ctx),
+		}
+		attrs := []any{"request_id", entry.RequestID, "tool", entry.Tool}
+		if call.Session != nil {
+			entry.Session = call.Session.ID()
+			ctx = logging.WithSession(ctx, call.Session)
+		}
+		if entry.Session != "" {
+			attrs = append(attrs, "session", entry.Session)
+		}
+		if entry.TraceID != "" {
+			attrs = append(attrs, "trace_id", entry.TraceID)
+		}
+		logger := logging.FromContext(ctx).With(attrs...)
+		ctx = logging.WithLogger(ctx, logger)
+		span.SetAttributes(tracing.String("mcp.request_id", entry.RequestID))
+		logger.Debug("Tool call started")
+
+		result, err := next(ctx, method, req)
+
+		entry.DurationMS = time.Since(started).Milliseconds()
+		if err != nil {
+			entry.IsError, entry.Error = true, err.Error()
+			span.SetError(err.Error())
+		} else if res, ok := result.(*sdk.CallToolResult); ok && res.IsError {
+			entry.IsError = true
+			span.SetError("tool call failed")
+		}
+		span.SetAttributes(tracing.Bool("mcp.is_error", entry.IsError))
+		h.audit.record(entry)
+
+		if err != nil {
+			logger.Error("Tool call failed", "duration_ms", entry.DurationMS, "error", err)
+		} else {
+			logger.Info("Tool call finished", "duration_ms", entry.DurationMS, "is_error", entry.IsError)
+		}
+		return result, err
+	}
+}
+
+// present redacts result and formats it as the reply to a tool call
+func (h *ToolHandler) present(ctx context.Context, result *domain.ExecutionResult, language string) *sdk.CallToolResult {
+	_, span := tracing.Start(ctx, "format.result")
+	defer span.End()
+	h.redact(result)
+	return FormatResult(result, language)
+}
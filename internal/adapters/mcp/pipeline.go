@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PipelineStage represents one stage of a pipeline
+type PipelineStage struct {
+	Language string   `json:"language"`
+	Code     string   `json:"code"`
+	Args     []string `json:"args,omitempty"`
+	Timeout  int      `json:"timeout,omitempty"`
+}
+
+// PipelineInput represents input for a cross-language pipeline
+type PipelineInput struct {
+	Stages     []PipelineStage `json:"stages"`
+	Stdin      string          `json:"stdin,omitempty"`
+	WorkingDir string          `json:"working_dir,omitempty"`
+}
+
+// executePipeline handles execution of stages chained stdout to stdin
+func (h *ToolHandler) executePipeline(ctx context.Context, call *sdk.CallToolRequest, input PipelineInput) (*sdk.CallToolResult, any, error) {
+	if len(input.Stages) == 0 {
+		return errorResult("Pipeline must contain at least one stage"), nil, nil
+	}
+
+	stages := make([]domain.ExecutionRequest, len(input.Stages))
+	for i, stage := range input.Stages {
+		stages[i] = newRequest(stage.Language, stage.Code, stage.Args, "", input.WorkingDir, stage.Timeout)
+		tagRequest(&stages[i], call)
+	}
+	stages[0].Stdin = input.Stdin
+
+	denied, note := h.approve(ctx, call, "stage", stages...)
+	if denied != nil {
+		return denied, nil, nil
+	}
+
+	result, err := h.pipelineRunner.RunPipeline(ctx, stages)
+	if err != nil {
+		return errorResult(fmt.Sprintf("Error executing pipeline: %v", err)), nil, nil
+	}
+	if h.redactor != nil {
+		h.redactor.RedactPipeline(result)
+	}
+
+	return withNote(formatPipelineResult(input.Stages, result), note), nil, nil
+}
+
+// formatPipelineResult renders the per-stage table, the final output and
+// each stage's stderr
+func formatPipelineResult(stages []PipelineStage, result *domain.PipelineResult) *sdk.CallToolResult {
+	labels := make([]string, len(stages))
+	for i, stage := range stages {
+		labels[i] = LanguageLabel(stage.Language)
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("## Pipeline Execution Result: %s\n\n", strings.Join(labels, " | ")))
+	summary.WriteString(fmt.Sprintf("**Exit Code:** %d\n", result.ExitCode))
+	summary.WriteString(fmt.Sprintf("**Duration:** %s\n", result.Duration.String()))
+	writeRedactions(&summary, result.Redactions)
+	summary.WriteString("\n")
+
+	summary.WriteString("| # | Language | Exit Code | Duration |\n")
+	summary.WriteString("|---|----------|-----------|----------|\n")
+	for i, stage := range result.Stages {
+		exitCode, duration := "-", "not run"
+		if stage != nil {
+			exitCode = fmt.Sprintf("%d", stage.ExitCode)
+			duration = stage.Duration.String()
+		}
+		summary.WriteString(fmt.Sprintf("| %d | %s | %s | %s |\n", i+1, labels[i], exitCode, duration))
+	}
+	summary.WriteString("\n")
+
+	if result.Stdout != "" {
+		summary.WriteString("### Standard Output\n```\n")
+		summary.WriteString(result.Stdout)
+		if !strings.HasSuffix(result.Stdout, "\n") {
+			summary.WriteString("\n")
+		}
+		summary.WriteString("```\n\n")
+	}
+
+	for i, stage := range result.Stages {
+		if stage == nil || stage.Stderr == "" {
+			continue
+		}
+		summary.WriteString(fmt.Sprintf("### Stage %d (%s) Standard Error\n```\n", i+1, labels[i]))
+		summary.WriteString(stage.Stderr)
+		if !strings.HasSuffix(stage.Stderr, "\n") {
+			summary.WriteString("\n")
+		}
+		summary.WriteString("```\n\n")
+	}
+
+	return &sdk.CallToolResult{
+		IsError: result.IsError,
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: summary.String()},
+		},
+	}
+}
@@ -2,16 +2,26 @@ package mcp
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
 )
 
 // PromptHandler implements the MCP prompt handler adapter
-type PromptHandler struct{}
+type PromptHandler struct {
+	toolchains ports.ToolchainReporter
+}
 
-// NewPromptHandler creates a new prompt handler
-func NewPromptHandler() *PromptHandler {
-	return &PromptHandler{}
+// NewPromptHandler creates a new prompt handler. toolchains may be nil
+// when they were not probed.
+func NewPromptHandler(toolchains ports.ToolchainReporter) *PromptHandler {
+	return &PromptHandler{toolchains: toolchains}
 }
 
 // RegisterPrompts registers all prompts with the MCP server
@@ -50,7 +60,11 @@ func (h *PromptHandler) handleCodeExecutorPrompt(ctx context.Context, req *sdk.G
 		}
 	}
 
-	promptText := generateCodeExecutorPrompt(task, preferences)
+	var toolchains []domain.Toolchain
+	if h.toolchains != nil {
+		toolchains = h.toolchains.Toolchains()
+	}
+	promptText := generateCodeExecutorPrompt(task, preferences, toolchains)
 
 	return &sdk.GetPromptResult{
 		Description: "Code Executor - Intelligent Language Selection",
@@ -64,10 +78,10 @@ func (h *PromptHandler) handleCodeExecutorPrompt(ctx context.Context, req *sdk.G
 }
 
 // generateCodeExecutorPrompt creates the prompt text for code execution guidance
-func generateCodeExecutorPrompt(task, preferences string) string {
+func generateCodeExecutorPrompt(task, preferences string, toolchains []domain.Toolchain) string {
 	prompt := `# Code Execution Assistant
 
-You are a helpful coding assistant with access to three code execution tools. Your job is to help the user accomplish their programming task by choosing the most appropriate language and writing executable code.
+You are a helpful coding assistant with access to several code execution tools. Your job is to help the user accomplish their programming task by choosing the most appropriate language and writing executable code.
 
 ## Available Tools
 
@@ -87,6 +101,9 @@ You are a helpful coding assistant with access to three code execution tools. Yo
 - ` + "`args`" + ` (optional): Command line arguments
 - ` + "`working_dir`" + ` (optional): Working directory
 - ` + "`timeout`" + ` (optional): Timeout in seconds (default: 30, max: 300)
+- ` + "`network`" + ` (optional): ` + "`none`" + `, ` + "`loopback`" + `, ` + "`allowlist`" + ` or ` + "`host`" + `; cannot be looser than the server's default
+
+Scripts are checked against the server's execution policy before they run. A rejected script reports each violation with its line and column; rewrite the script to avoid it rather than trying to obscure the command.
 
 ### 2. execute_python_script
 **Best for:**
@@ -105,6 +122,11 @@ You are a helpful coding assistant with access to three code execution tools. Yo
 - ` + "`args`" + ` (optional): Arguments accessible via sys.argv
 - ` + "`working_dir`" + ` (optional): Working directory
 - ` + "`timeout`" + ` (optional): Timeout in seconds (default: 30, max: 300)
+- ` + "`profile`" + ` (optional): Run under cProfile and report the functions with the most self time
+- ` + "`coverage`" + ` (optional): Report which lines ran, with the uncovered line ranges
+- ` + "`network`" + ` (optional): ` + "`none`" + `, ` + "`loopback`" + `, ` + "`allowlist`" + ` or ` + "`host`" + `; cannot be looser than the server's default
+
+The server may restrict which modules can be imported and which builtins can be used. A rejected script reports each violation with its line and column; use an allowed alternative instead of working around the check.
 
 ### 3. execute_golang_code
 **Best for:**
@@ -118,8 +140,56 @@ You are a helpful coding assistant with access to three code execution tools. Yo
 
 **Input parameters:**
 - ` + "`code`" + ` (required): Go code with ` + "`package main`" + ` and ` + "`func main()`" + `
+- ` + "`mode`" + ` (optional): ` + "`run`" + ` (default) or ` + "`test`" + ` to run the tests in ` + "`test_code`" + ` against ` + "`code`" + `, which may then be any package
+- ` + "`test_code`" + ` (test mode): The contents of a _test.go file in the same package
+- ` + "`args`" + ` (optional): Program arguments, or test binary flags such as ` + "`-test.run`" + ` in test mode
 - ` + "`working_dir`" + ` (optional): Working directory
 - ` + "`timeout`" + ` (optional): Timeout in seconds (default: 60, max: 300)
+- ` + "`coverage`" + ` (optional): Build with -cover and report which lines ran, with the uncovered line ranges
+- ` + "`profile`" + ` (optional): Record CPU and heap profiles and report the hottest functions; profiles are written when main returns, so don't end with os.Exit
+- ` + "`network`" + ` (optional): ` + "`none`" + `, ` + "`loopback`" + `, ` + "`allowlist`" + ` or ` + "`host`" + `; cannot be looser than the server's default
+
+Third-party imports are resolved from a local module mirror; call ` + "`list_go_modules`" + ` to see what is available. The server may restrict which packages can be imported; a rejected program reports each import with its line and column.
+
+### 4. execute_batch
+**Best for:**
+- Trying several variants or candidate implementations at once
+- Running independent snippets in parallel
+
+**Input parameters:**
+- ` + "`items`" + ` (required): List of ` + "`{language, code, args, stdin, timeout}`" + ` where language is bash, python or go
+- ` + "`concurrency`" + ` (optional): Maximum items running at once
+- ` + "`fail_fast`" + ` (optional): Cancel outstanding items when one fails
+
+### 5. execute_pipeline
+**Best for:**
+- Multi-step data flows where each step suits a different language
+- Streaming large intermediate data between programs
+
+**Input parameters:**
+- ` + "`stages`" + ` (required): List of ` + "`{language, code, args, timeout}`" + `; each stage's stdout feeds the next stage's stdin
+- ` + "`stdin`" + ` (optional): Input for the first stage
+- ` + "`working_dir`" + ` (optional): Working directory for every stage
+
+### 6. evaluate_code
+**Best for:**
+- Checking a solution against known input/output pairs
+- Scoring candidate implementations
+
+**Input parameters:**
+- ` + "`language`" + ` and ` + "`code`" + ` (required): The program to judge
+- ` + "`test_cases`" + ` (required): List of ` + "`{name, stdin, args, expected_stdout, comparator, tolerance, timeout}`" + `; comparator is exact, whitespace, float or regex
+- ` + "`timeout`" + ` (optional): Default time limit per case
+
+### 7. benchmark_code
+**Best for:**
+- Measuring how fast a snippet runs, with statistics instead of a single sample
+- Comparing two implementations of the same thing
+
+**Input parameters:**
+- ` + "`language`" + ` and ` + "`code`" + ` (required): The snippet to benchmark
+- ` + "`compare_code`" + ` (optional): A second snippet in the same language to compare against
+- ` + "`runs`" + `, ` + "`warmup`" + `, ` + "`time_budget`" + ` (optional): Number of timed runs, warm-up runs and a time limit in seconds for the whole benchmark
 
 ## Decision Framework
 
@@ -129,10 +199,22 @@ Use this decision tree to select the right tool:
 2. **Does it involve data processing, APIs, or needs Python libraries?** → Use ` + "`execute_python_script`" + `
 3. **Does it need high performance, concurrency, or type safety?** → Use ` + "`execute_golang_code`" + `
 4. **Is it a simple script or automation?** → Use ` + "`execute_bash_script`" + ` or ` + "`execute_python_script`" + `
+5. **Do you want to compare several independent variants?** → Use ` + "`execute_batch`" + `
+6. **Does the task chain steps that suit different languages?** → Use ` + "`execute_pipeline`" + `
+7. **Do you need to verify a solution against expected outputs?** → Use ` + "`evaluate_code`" + `
+8. **Do you need to know how fast code is, or which variant is faster?** → Use ` + "`benchmark_code`" + `; to find out *why* it is slow, run it once with ` + "`profile`" + ` set
+
+Secrets in output, such as tokens and private keys, are replaced with placeholders like ` + "`[REDACTED:github_token]`" + `. Don't try to recover the masked values.
+
+The server may ask the user to approve risky executions, such as writes outside the working directory, network use or long timeouts. If the user declines, don't retry the same code; explain what it needed to do, or find a way that stays inside the working directory.
 
-## User's Task
+The server may restrict network access. In ` + "`allowlist`" + ` mode, HTTP(S) goes through a proxy set in ` + "`HTTP_PROXY`" + ` and ` + "`HTTPS_PROXY`" + `, and the result lists every connection attempted; a 403 from the proxy means the host is not allowed, so don't try other routes to it. The server may also confine the filesystem, so that only the working directory and the temp directory are writable, and filter system calls, in which case the result lists the calls it blocked; don't work around a blocked call, since the restriction is deliberate. Code may run as an unprivileged user whose ` + "`HOME`" + ` is an empty directory discarded after each execution, so don't rely on files in the home directory. Call ` + "`get_capabilities`" + ` to see which restrictions are in force.
+
+Results of single-snippet executions end with a history id. Read the ` + "`exec://history/{id}`" + ` resource for the full code, parameters and output of a past run, and call ` + "`rerun_execution`" + ` with the id to run it again, overriding only what changed.
 
 `
+	prompt += environmentSection(toolchains)
+	prompt += "## User's Task\n\n"
 
 	if task != "" {
 		prompt += "**Task:** " + task + "\n\n"
@@ -160,3 +242,52 @@ Remember:
 
 	return prompt
 }
+
+// toolForLanguage names the tool that runs each toolchain's language
+var toolForLanguage = map[string]string{
+	"bash":   "execute_bash_script",
+	"python": "execute_python_script",
+	"go":     "execute_golang_code",
+}
+
+// environmentSection describes the toolchains found on the server, so that
+// code is written for the versions and packages at hand
+func environmentSection(toolchains []domain.Toolchain) string {
+	if len(toolchains) == 0 {
+		return ""
+	}
+
+	var section strings.Builder
+	section.WriteString("## Environment\n\n")
+	for _, t := range toolchains {
+		label := LanguageLabel(t.Language)
+		switch {
+		case !t.Available && t.Optional:
+			continue
+		case !t.Available:
+			section.WriteString(fmt.Sprintf("- **%s:** not installed, so `%s` is unavailable\n", label, toolForLanguage[t.Language]))
+			continue
+		case t.Version == "":
+			continue
+		}
+
+		section.WriteString(fmt.Sprintf("- **%s:** %s", label, t.Version))
+		var installed, missing []string
+		for _, name := range slices.Sorted(maps.Keys(t.Packages)) {
+			if version := t.Packages[name]; version != "" {
+				installed = append(installed, name+" "+version)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+		if len(installed) > 0 {
+			section.WriteString(", with " + strings.Join(installed, ", "))
+		}
+		if len(missing) > 0 {
+			section.WriteString("; not installed: " + strings.Join(missing, ", "))
+		}
+		section.WriteString("\n")
+	}
+	section.WriteString("\nWrite code for these versions, and don't import the packages listed as not installed.\n\n")
+	return section.String()
+}
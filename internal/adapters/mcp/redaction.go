@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/redact"
+)
+
+// NewRedactor builds the redactor described by cfg, or returns nil when
+// redaction is disabled. Secret values are read from the server's
+// environment, which executed code inherits.
+func NewRedactor(cfg config.RedactionConfig) *redact.Redactor {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var patterns []redact.Pattern
+	for _, name := range cfg.Builtins {
+		expr, ok := redact.Builtins[name]
+		if !ok {
+			slog.Warn("Unknown built-in redaction pattern ignored", "pattern", name)
+			continue
+		}
+		patterns = append(patterns, redact.Pattern{Category: name, Regexp: regexp.MustCompile(expr)})
+	}
+	for _, p := range cfg.Patterns {
+		// Custom patterns were compiled when the config was loaded
+		patterns = append(patterns, redact.Pattern{Category: p.Name, Regexp: regexp.MustCompile(p.Regex)})
+	}
+
+	secrets := redact.EnvSecrets(os.Environ(), cfg.SecretEnv)
+	return redact.New(patterns, secrets, redact.Entropy{
+		MinBits:   cfg.MinEntropyBits,
+		MinLength: cfg.MinEntropyLength,
+	})
+}
+
+// redact masks secrets in the output of result
+func (h *ToolHandler) redact(result *domain.ExecutionResult) {
+	if h.redactor != nil {
+		h.redactor.RedactResult(result)
+	}
+}
+
+// redactText masks secrets in text derived from execution output
+func (h *ToolHandler) redactText(text string) string {
+	if h.redactor == nil {
+		return text
+	}
+	return h.redactor.Redact(text, nil)
+}
+
+// writeRedactions reports how many secrets were masked, by category
+func writeRedactions(summary *strings.Builder, redactions map[string]int) {
+	if len(redactions) == 0 {
+		return
+	}
+	categories := make([]string, 0, len(redactions))
+	for category := range redactions {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	parts := make([]string, len(categories))
+	for i, category := range categories {
+		parts[i] = fmt.Sprintf("%d %s", redactions[category], category)
+	}
+	summary.WriteString(fmt.Sprintf("**Redacted:** %s\n", strings.Join(parts, ", ")))
+}
@@ -0,0 +1,31 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+
+	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// releaseClosedSessions frees what the sandbox held for each session once
+// the session closes, watching from the moment the client finishes
+// initializing it
+func releaseClosedSessions(releaser ports.SessionReleaser) sdk.Middleware {
+	return func(next sdk.MethodHandler) sdk.MethodHandler {
+		return func(ctx context.Context, method string, req sdk.Request) (sdk.Result, error) {
+			result, err := next(ctx, method, req)
+			ss, ok := req.GetSession().(*sdk.ServerSession)
+			if method == "notifications/initialized" && ok && err == nil {
+				go func() {
+					ss.Wait()
+					if err := releaser.ReleaseSession(ss.ID()); err != nil {
+						slog.Error("Releasing the sandbox resources of a closed session failed", "session", ss.ID(), "error", err)
+					}
+				}()
+			}
+			return result, err
+		}
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aravi/code_execution_mcp/internal/config"
 	"github.com/aravi/code_execution_mcp/internal/core/domain"
 	"github.com/aravi/code_execution_mcp/internal/core/ports"
+	"github.com/aravi/code_execution_mcp/internal/core/redact"
 	sdk "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -15,14 +17,31 @@ type ToolHandler struct {
 	shellExecutor  ports.CodeExecutor
 	pythonExecutor ports.CodeExecutor
 	goExecutor     ports.CodeExecutor
+	pipelineRunner ports.PipelineRunner
+	capabilities   ports.CapabilityReporter
+	toolchains     ports.ToolchainReporter
+	history        ports.ExecutionHistory
+	cfg            *config.Config
+	redactor       *redact.Redactor
+	audit          *auditLog
 }
 
-// NewToolHandler creates a new tool handler with the given executors
-func NewToolHandler(shellExec, pythonExec, goExec ports.CodeExecutor) *ToolHandler {
+// NewToolHandler creates a new tool handler with the given executors.
+// capabilities may be nil when the server has no sandbox to report on,
+// toolchains when they were not probed, and history when executions are
+// not kept.
+func NewToolHandler(shellExec, pythonExec, goExec ports.CodeExecutor, pipeline ports.PipelineRunner, capabilities ports.CapabilityReporter, toolchains ports.ToolchainReporter, history ports.ExecutionHistory, cfg *config.Config) *ToolHandler {
 	return &ToolHandler{
 		shellExecutor:  shellExec,
 		pythonExecutor: pythonExec,
 		goExecutor:     goExec,
+		pipelineRunner: pipeline,
+		capabilities:   capabilities,
+		toolchains:     toolchains,
+		history:        history,
+		cfg:            cfg,
+		redactor:       NewRedactor(cfg.Redaction),
+		audit:          openAuditLog(cfg.Audit.File),
 	}
 }
 
@@ -32,6 +51,7 @@ type BashInput struct {
 	Args       []string `json:"args,omitempty"`
 	WorkingDir string   `json:"working_dir,omitempty"`
 	Timeout    int      `json:"timeout,omitempty"`
+	Network    string   `json:"network,omitempty"`
 }
 
 // PythonInput represents input for Python script execution
@@ -40,44 +60,138 @@ type PythonInput struct {
 	Args       []string `json:"args,omitempty"`
 	WorkingDir string   `json:"working_dir,omitempty"`
 	Timeout    int      `json:"timeout,omitempty"`
+	Profile    bool     `json:"profile,omitempty"`
+	Coverage   bool     `json:"coverage,omitempty"`
+	Network    string   `json:"network,omitempty"`
 }
 
-// GolangInput represents input for Go code execution
+// GolangInput represents input for Go code execution. In test mode Code is
+// the package under test, which need not be package main, and TestCode
+// holds its _test.go file.
 type GolangInput struct {
-	Code       string `json:"code"`
-	WorkingDir string `json:"working_dir,omitempty"`
-	Timeout    int    `json:"timeout,omitempty"`
+	Code       string   `json:"code"`
+	Mode       string   `json:"mode,omitempty"`
+	TestCode   string   `json:"test_code,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+	Timeout    int      `json:"timeout,omitempty"`
+	Profile    bool     `json:"profile,omitempty"`
+	Coverage   bool     `json:"coverage,omitempty"`
+	Network    string   `json:"network,omitempty"`
 }
 
-// RegisterTools registers all execution tools with the MCP server
+// ListGoModulesInput represents input for listing available Go modules
+type ListGoModulesInput struct {
+	Filter string `json:"filter,omitempty"`
+}
+
+// RegisterTools registers the execution tools with the MCP server. Tools
+// for a single language are only registered when its toolchain is present,
+// and those for several languages when any is.
 func (h *ToolHandler) RegisterTools(server *sdk.Server) {
+	server.AddReceivingMiddleware(h.observeToolCalls)
+	if releaser, ok := h.capabilities.(ports.SessionReleaser); ok {
+		server.AddReceivingMiddleware(releaseClosedSessions(releaser))
+	}
+	hasBash, hasPython, hasGo := h.hasToolchain("bash"), h.hasToolchain("python"), h.hasToolchain("go")
+	hasAny := hasBash || hasPython || hasGo
+
 	// Tool 1: Execute Bash/Zsh Script
-	sdk.AddTool[BashInput, any](server, &sdk.Tool{
-		Name:        "execute_bash_script",
-		Description: "Execute a bash or zsh shell script. Use this for shell commands, file operations, system administration tasks, or when you need to chain multiple shell commands together. Works on Unix-like systems (Linux, macOS) and Windows with Git Bash or WSL.",
-	}, h.executeBashScript)
+	if hasBash {
+		sdk.AddTool[BashInput, any](server, &sdk.Tool{
+			Name:        "execute_bash_script",
+			Description: "Execute a bash or zsh shell script. Use this for shell commands, file operations, system administration tasks, or when you need to chain multiple shell commands together. Set network to restrict what the code can reach, though never beyond the server's default: none (no network at all), loopback (a private loopback interface only), allowlist (HTTP(S) through a proxy that only reaches the configured hosts; attempted connections are listed in the result) or host. Works on Unix-like systems (Linux, macOS) and Windows with Git Bash or WSL.",
+		}, h.executeBashScript)
+	}
 
 	// Tool 2: Execute Python Script
-	sdk.AddTool[PythonInput, any](server, &sdk.Tool{
-		Name:        "execute_python_script",
-		Description: "Execute Python code. Ideal for data processing, mathematical computations, machine learning tasks, API interactions, and any task that benefits from Python's extensive library ecosystem. Requires Python 3 to be installed.",
-	}, h.executePythonScript)
+	if hasPython {
+		sdk.AddTool[PythonInput, any](server, &sdk.Tool{
+			Name:        "execute_python_script",
+			Description: "Execute Python code. Ideal for data processing, mathematical computations, machine learning tasks, API interactions, and any task that benefits from Python's extensive library ecosystem. Set profile to run under cProfile and get a table of the functions with the most self time plus the raw .pstats file, or set coverage to report per-line coverage with the uncovered line ranges. Set network to restrict what the code can reach, though never beyond the server's default: none (no network at all), loopback (a private loopback interface only), allowlist (HTTP(S) through a proxy that only reaches the configured hosts; attempted connections are listed in the result) or host. Requires Python 3 to be installed.",
+		}, h.executePythonScript)
+	}
 
 	// Tool 3: Execute Go Code
-	sdk.AddTool[GolangInput, any](server, &sdk.Tool{
-		Name:        "execute_golang_code",
-		Description: "Execute Go (Golang) code. Best for high-performance tasks, concurrent operations, system programming, and when you need type safety and compiled performance. The code must include 'package main' and 'func main()'. Requires Go to be installed.",
-	}, h.executeGolangCode)
+	if hasGo {
+		sdk.AddTool[GolangInput, any](server, &sdk.Tool{
+			Name:        "execute_golang_code",
+			Description: "Execute Go (Golang) code. Best for high-performance tasks, concurrent operations, system programming, and when you need type safety and compiled performance. The code must include 'package main' and 'func main()'. Set mode to 'test' and put a _test.go file in test_code to run its tests (verbosely) against code, which may then be any package; args are passed to the test binary, e.g. ['-test.run', 'TestX']. Set coverage to report per-line coverage with the uncovered line ranges, in either mode. Set profile to record CPU and heap pprof profiles and get the hottest functions of each plus the raw .pprof files; profiles are written when main returns, so avoid os.Exit when profiling. Set network to restrict what the code can reach, though never beyond the server's default: none (no network at all), loopback (a private loopback interface only), allowlist (HTTP(S) through a proxy that only reaches the configured hosts; attempted connections are listed in the result) or host. Requires Go to be installed.",
+		}, h.executeGolangCode)
+	}
+
+	// Tool 4: Execute several snippets concurrently
+	if hasAny {
+		sdk.AddTool[BatchInput, any](server, &sdk.Tool{
+			Name:        "execute_batch",
+			Description: "Execute several code snippets concurrently and return a combined summary table plus each item's output. Use this to try multiple variants or candidate implementations at once instead of making sequential calls. Each item names its language (bash, python or go) and may pass args, stdin and a timeout. Set fail_fast to cancel the remaining items as soon as one fails. Its runs are not kept in the execution history.",
+		}, h.executeBatch)
+	}
+
+	// Tool 5: Chain snippets stdout to stdin
+	if hasAny {
+		sdk.AddTool[PipelineInput, any](server, &sdk.Tool{
+			Name:        "execute_pipeline",
+			Description: "Execute a pipeline of code snippets, possibly in different languages, where each stage's stdout is streamed into the next stage's stdin through OS pipes (like `a | b | c` in a shell). Use this for tasks such as generating data in Python, crunching it in Go and post-processing it with awk. Reports every stage's exit code and stderr; the pipeline fails if any stage fails (pipefail), with the exit code of the last failing stage. Its runs are not kept in the execution history.",
+		}, h.executePipeline)
+	}
+
+	// Tool 6: Judge code against test cases
+	if hasAny {
+		sdk.AddTool[EvaluateInput, any](server, &sdk.Tool{
+			Name:        "evaluate_code",
+			Description: "Run a program against a list of test cases and report pass/fail per case with an overall score. Each case gives stdin, args and the expected stdout, plus an optional comparator: exact (default; ignores trailing newlines), whitespace (compares whitespace-separated tokens), float (numeric tokens within tolerance, default 1e-6) or regex (expected_stdout is a regex that must match the whole output). Each case runs with its own time limit; failures include a diff of expected vs actual output. Use this to validate solutions instead of writing test loops inside the script. Its runs are not kept in the execution history.",
+		}, h.evaluateCode)
+	}
+
+	// Tool 7: Benchmark code
+	if hasAny {
+		sdk.AddTool[BenchmarkInput, any](server, &sdk.Tool{
+			Name:        "benchmark_code",
+			Description: "Benchmark a snippet by running it repeatedly after warm-up runs, and report min/median/mean/p95/max/stddev of the run time plus mean CPU time and peak memory. For Go, compile time is measured separately and excluded from the samples. Set runs (default 10) and optionally time_budget in seconds to stop early. Set compare_code to benchmark a second snippet in the same language; runs are interleaved and the result includes the relative speedup with a Welch's t-test significance estimate. Note that samples include interpreter start-up, so benchmark work that dominates it. Its runs are not kept in the execution history.",
+		}, h.benchmarkCode)
+	}
+
+	// Tool 8: List Go modules available to execute_golang_code
+	if _, ok := h.goExecutor.(ports.ModuleCatalog); ok && hasGo {
+		sdk.AddTool[ListGoModulesInput, any](server, &sdk.Tool{
+			Name:        "list_go_modules",
+			Description: "List the third-party Go modules and versions that execute_golang_code can import. Use this before importing anything outside the standard library, since builds may run offline against a local module mirror. The optional filter matches a substring of the module path.",
+		}, h.listGoModules)
+	}
+
+	// Tool 9: Report toolchains and sandbox capabilities
+	if h.capabilities != nil || h.toolchains != nil {
+		sdk.AddTool[GetCapabilitiesInput, any](server, &sdk.Tool{
+			Name:        "get_capabilities",
+			Description: "Report the toolchains and sandboxing features of the host the server runs on: the path and version of bash, zsh, Python and Go, the versions of common Python packages such as numpy and pandas, the Go environment, whether Landlock filesystem confinement (and which ABI version), network namespaces, seccomp system call filtering, running executions as another user and cgroups are available, and whether the server is configured to use them. Use this to learn which language versions and libraries to write code for, and what executed code will be allowed to read, write and reach.",
+		}, h.getCapabilities)
+	}
+
+	// Tool 10: Re-run a past execution
+	if h.history != nil && hasAny {
+		sdk.AddTool[RerunInput, any](server, &sdk.Tool{
+			Name:        "rerun_execution",
+			Description: "Run a past execute_bash_script, execute_python_script, execute_golang_code or rerun_execution call again by its history id, shown in each result and listed by the exec://history resource. Optionally override code, args, stdin, working_dir, timeout or network; everything else is kept. The full code, parameters and untruncated output of past runs can be read from the exec://history/{id} resources.",
+		}, h.rerunExecution)
+	}
 }
 
 // executeBashScript handles bash/zsh script execution
-func (h *ToolHandler) executeBashScript(ctx context.Context, _ *sdk.CallToolRequest, input BashInput) (*sdk.CallToolResult, any, error) {
+func (h *ToolHandler) executeBashScript(ctx context.Context, call *sdk.CallToolRequest, input BashInput) (*sdk.CallToolResult, any, error) {
 	req := domain.ExecutionRequest{
 		Language:   "bash",
 		Script:     input.Script,
 		Args:       input.Args,
 		WorkingDir: input.WorkingDir,
 		Timeout:    input.Timeout,
+		Network:    input.Network,
+	}
+	tagRequest(&req, call)
+
+	denied, note := h.approve(ctx, call, "", req)
+	if denied != nil {
+		return denied, nil, nil
 	}
 
 	result, err := h.shellExecutor.Execute(ctx, req)
@@ -90,17 +204,26 @@ func (h *ToolHandler) executeBashScript(ctx context.Context, _ *sdk.CallToolRequ
 		}, nil, nil
 	}
 
-	return formatResult(result, "Bash"), nil, nil
+	return withNote(h.remember(ctx, h.present(ctx, result, "Bash"), req, result, ""), note), nil, nil
 }
 
 // executePythonScript handles Python code execution
-func (h *ToolHandler) executePythonScript(ctx context.Context, _ *sdk.CallToolRequest, input PythonInput) (*sdk.CallToolResult, any, error) {
+func (h *ToolHandler) executePythonScript(ctx context.Context, call *sdk.CallToolRequest, input PythonInput) (*sdk.CallToolResult, any, error) {
 	req := domain.ExecutionRequest{
 		Language:   "python",
 		Code:       input.Code,
 		Args:       input.Args,
 		WorkingDir: input.WorkingDir,
 		Timeout:    input.Timeout,
+		Profile:    input.Profile,
+		Coverage:   input.Coverage,
+		Network:    input.Network,
+	}
+	tagRequest(&req, call)
+
+	denied, note := h.approve(ctx, call, "", req)
+	if denied != nil {
+		return denied, nil, nil
 	}
 
 	result, err := h.pythonExecutor.Execute(ctx, req)
@@ -113,16 +236,28 @@ func (h *ToolHandler) executePythonScript(ctx context.Context, _ *sdk.CallToolRe
 		}, nil, nil
 	}
 
-	return formatResult(result, "Python"), nil, nil
+	return withNote(h.remember(ctx, h.present(ctx, result, "Python"), req, result, ""), note), nil, nil
 }
 
 // executeGolangCode handles Go code execution
-func (h *ToolHandler) executeGolangCode(ctx context.Context, _ *sdk.CallToolRequest, input GolangInput) (*sdk.CallToolResult, any, error) {
+func (h *ToolHandler) executeGolangCode(ctx context.Context, call *sdk.CallToolRequest, input GolangInput) (*sdk.CallToolResult, any, error) {
 	req := domain.ExecutionRequest{
 		Language:   "go",
 		Code:       input.Code,
+		Mode:       input.Mode,
+		TestCode:   input.TestCode,
+		Args:       input.Args,
 		WorkingDir: input.WorkingDir,
 		Timeout:    input.Timeout,
+		Profile:    input.Profile,
+		Coverage:   input.Coverage,
+		Network:    input.Network,
+	}
+	tagRequest(&req, call)
+
+	denied, note := h.approve(ctx, call, "", req)
+	if denied != nil {
+		return denied, nil, nil
 	}
 
 	result, err := h.goExecutor.Execute(ctx, req)
@@ -135,18 +270,256 @@ func (h *ToolHandler) executeGolangCode(ctx context.Context, _ *sdk.CallToolRequ
 		}, nil, nil
 	}
 
-	return formatResult(result, "Go"), nil, nil
+	return withNote(h.remember(ctx, h.present(ctx, result, "Go"), req, result, ""), note), nil, nil
+}
+
+// listGoModules handles listing of the Go modules available for import
+func (h *ToolHandler) listGoModules(ctx context.Context, _ *sdk.CallToolRequest, input ListGoModulesInput) (*sdk.CallToolResult, any, error) {
+	modules, err := h.goExecutor.(ports.ModuleCatalog).ListModules(ctx)
+	if err != nil {
+		return &sdk.CallToolResult{
+			IsError: true,
+			Content: []sdk.Content{
+				&sdk.TextContent{Text: fmt.Sprintf("Error listing Go modules: %v", err)},
+			},
+		}, nil, nil
+	}
+
+	var text strings.Builder
+	text.WriteString("## Available Go Modules\n\n")
+	count := 0
+	for _, module := range modules {
+		if input.Filter != "" && !strings.Contains(module.Path, input.Filter) {
+			continue
+		}
+		text.WriteString(fmt.Sprintf("- `%s`: %s\n", module.Path, strings.Join(module.Versions, ", ")))
+		count++
+	}
+	if count == 0 {
+		text.WriteString("No modules found. Only the standard library is available.\n")
+	}
+
+	return &sdk.CallToolResult{
+		Content: []sdk.Content{
+			&sdk.TextContent{Text: text.String()},
+		},
+	}, nil, nil
+}
+
+// executorFor returns the executor that supports language, when its
+// toolchain is present
+func (h *ToolHandler) executorFor(language string) (ports.CodeExecutor, bool) {
+	executors := []struct {
+		executor  ports.CodeExecutor
+		toolchain string
+	}{
+		{h.shellExecutor, "bash"},
+		{h.pythonExecutor, "python"},
+		{h.goExecutor, "go"},
+	}
+	for _, e := range executors {
+		if e.executor.Supports(language) {
+			return e.executor, h.hasToolchain(e.toolchain)
+		}
+	}
+	return nil, false
+}
+
+// hasToolchain reports whether the toolchain for language was found, or
+// was not probed for
+func (h *ToolHandler) hasToolchain(language string) bool {
+	if h.toolchains == nil {
+		return true
+	}
+	for _, toolchain := range h.toolchains.Toolchains() {
+		if toolchain.Language == language && !toolchain.Optional {
+			return toolchain.Available
+		}
+	}
+	return true
+}
+
+// newRequest builds an ExecutionRequest, placing the source in Script for
+// shell languages and in Code for everything else
+func newRequest(language, code string, args []string, stdin, workingDir string, timeout int) domain.ExecutionRequest {
+	req := domain.ExecutionRequest{
+		Language:   language,
+		Args:       args,
+		Stdin:      stdin,
+		WorkingDir: workingDir,
+		Timeout:    timeout,
+	}
+	switch language {
+	case "bash", "zsh", "shell":
+		req.Script = code
+	default:
+		req.Code = code
+	}
+	return req
 }
 
-// formatResult formats the execution result for MCP response
-func formatResult(result *domain.ExecutionResult, language string) *sdk.CallToolResult {
+// tagRequest records the session and tool that call arrived on in req
+func tagRequest(req *domain.ExecutionRequest, call *sdk.CallToolRequest) {
+	if call == nil {
+		return
+	}
+	if call.Session != nil {
+		req.Session = call.Session.ID()
+	}
+	if call.Params != nil {
+		req.Tool = call.Params.Name
+	}
+}
+
+// LanguageLabel returns the display name used in result headings
+func LanguageLabel(language string) string {
+	switch language {
+	case "bash", "zsh", "shell":
+		return "Bash"
+	case "python", "python3":
+		return "Python"
+	case "go", "golang":
+		return "Go"
+	default:
+		return language
+	}
+}
+
+// FormatResult formats the execution result for MCP response. The CLI
+// prints the same response, so it matches what clients see.
+func FormatResult(result *domain.ExecutionResult, language string) *sdk.CallToolResult {
 	var summary strings.Builder
 	summary.WriteString(fmt.Sprintf("## %s Execution Result\n\n", language))
+	writeResultDetails(&summary, result, "###")
+	if result.Profile != nil {
+		writeProfile(&summary, result.Profile)
+	}
+	if result.Coverage != nil {
+		writeCoverage(&summary, result.Coverage)
+	}
+	if len(result.Connections) > 0 {
+		writeConnections(&summary, result.Connections)
+	}
+
+	content := []sdk.Content{
+		&sdk.TextContent{Text: summary.String()},
+	}
+	for _, artifact := range result.Artifacts {
+		content = append(content, &sdk.EmbeddedResource{
+			Resource: &sdk.ResourceContents{
+				URI:      "artifact:///" + artifact.Name,
+				MIMEType: artifact.MIMEType,
+				Blob:     artifact.Data,
+			},
+		})
+	}
+
+	return &sdk.CallToolResult{
+		IsError: result.IsError,
+		Content: content,
+	}
+}
+
+// writeCoverage renders the per-file line coverage and uncovered ranges
+func writeCoverage(summary *strings.Builder, report *domain.CoverageReport) {
+	if !strings.HasSuffix(summary.String(), "\n\n") {
+		summary.WriteString("\n")
+	}
+	if report.Tool != "" {
+		summary.WriteString(fmt.Sprintf("### Coverage (%s)\n\n", report.Tool))
+	} else {
+		summary.WriteString("### Coverage\n\n")
+	}
+	if len(report.Files) == 0 {
+		summary.WriteString("No coverage data was written; the process may have been killed before it exited.\n\n")
+		return
+	}
+
+	summary.WriteString("| File | Lines | Covered | Coverage | Uncovered Lines |\n")
+	summary.WriteString("|------|-------|---------|----------|-----------------|\n")
+	for _, file := range report.Files {
+		ranges := make([]string, len(file.Uncovered))
+		for i, r := range file.Uncovered {
+			ranges[i] = fmt.Sprintf("%d", r.Start)
+			if r.End != r.Start {
+				ranges[i] += fmt.Sprintf("-%d", r.End)
+			}
+		}
+		uncovered := strings.Join(ranges, ", ")
+		if uncovered == "" {
+			uncovered = "-"
+		}
+		summary.WriteString(fmt.Sprintf("| %s | %d | %d | %.1f%% | %s |\n", file.Path, file.Lines, file.Covered, file.Percent(), uncovered))
+	}
+	summary.WriteString("\n")
+}
+
+// writeConnections renders the connections attempted through the egress
+// proxy
+func writeConnections(summary *strings.Builder, connections []domain.Connection) {
+	if !strings.HasSuffix(summary.String(), "\n\n") {
+		summary.WriteString("\n")
+	}
+	summary.WriteString("### Connections\n\n")
+	summary.WriteString("| Host | Port | Allowed | Error |\n")
+	summary.WriteString("|------|------|---------|-------|\n")
+	for _, c := range connections {
+		errText := c.Error
+		if errText == "" {
+			errText = "-"
+		}
+		summary.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", c.Host, c.Port, yesNo(c.Allowed), errText))
+	}
+	summary.WriteString("\n")
+}
+
+// writeProfile renders each profile table as markdown
+func writeProfile(summary *strings.Builder, profile *domain.Profile) {
+	if !strings.HasSuffix(summary.String(), "\n\n") {
+		summary.WriteString("\n")
+	}
+	summary.WriteString(fmt.Sprintf("### Profile (%s)\n\n", profile.Tool))
+	if profile.Warning != "" {
+		summary.WriteString(profile.Warning + "\n\n")
+	}
+	for _, table := range profile.Tables {
+		summary.WriteString(fmt.Sprintf("#### %s\n\n", table.Title))
+		if len(table.Rows) == 0 {
+			summary.WriteString("No samples were recorded; the run was too short to profile.\n\n")
+			continue
+		}
+		summary.WriteString("| " + strings.Join(table.Columns, " | ") + " |\n")
+		summary.WriteString(strings.Repeat("|---", len(table.Columns)) + "|\n")
+		for _, row := range table.Rows {
+			summary.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+		summary.WriteString("\n")
+	}
+}
+
+// writeResultDetails writes the exit code, timings and output of a result.
+// heading is the markdown heading prefix for the output sections.
+func writeResultDetails(summary *strings.Builder, result *domain.ExecutionResult, heading string) {
 	summary.WriteString(fmt.Sprintf("**Exit Code:** %d\n", result.ExitCode))
-	summary.WriteString(fmt.Sprintf("**Duration:** %s\n\n", result.Duration.String()))
+	summary.WriteString(fmt.Sprintf("**Duration:** %s\n", result.Duration.String()))
+	if result.CompileDuration > 0 {
+		summary.WriteString(fmt.Sprintf("**Compile Time:** %s\n", result.CompileDuration.String()))
+		summary.WriteString(fmt.Sprintf("**Run Time:** %s\n", result.RunDuration.String()))
+	}
+	if result.BuildCache != domain.CacheDisabled {
+		summary.WriteString(fmt.Sprintf("**Build Cache:** %s\n", result.BuildCache))
+	}
+	if result.WorkerPool != domain.CacheDisabled {
+		summary.WriteString(fmt.Sprintf("**Worker Pool:** %s\n", result.WorkerPool))
+	}
+	if len(result.BlockedSyscalls) > 0 {
+		summary.WriteString(fmt.Sprintf("**Blocked Syscalls:** %s\n", strings.Join(result.BlockedSyscalls, ", ")))
+	}
+	writeRedactions(summary, result.Redactions)
+	summary.WriteString("\n")
 
 	if result.Stdout != "" {
-		summary.WriteString("### Standard Output\n```\n")
+		summary.WriteString(heading + " Standard Output\n```\n")
 		summary.WriteString(result.Stdout)
 		if !strings.HasSuffix(result.Stdout, "\n") {
 			summary.WriteString("\n")
@@ -155,18 +528,11 @@ func formatResult(result *domain.ExecutionResult, language string) *sdk.CallTool
 	}
 
 	if result.Stderr != "" {
-		summary.WriteString("### Standard Error\n```\n")
+		summary.WriteString(heading + " Standard Error\n```\n")
 		summary.WriteString(result.Stderr)
 		if !strings.HasSuffix(result.Stderr, "\n") {
 			summary.WriteString("\n")
 		}
 		summary.WriteString("```\n")
 	}
-
-	return &sdk.CallToolResult{
-		IsError: result.IsError,
-		Content: []sdk.Content{
-			&sdk.TextContent{Text: summary.String()},
-		},
-	}
 }
@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+	"github.com/aravi/code_execution_mcp/internal/core/ports"
+)
+
+// Histogram buckets for durations in seconds and output sizes in bytes
+var (
+	durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+	sizeBuckets     = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+)
+
+// Metrics records what the executors do and serves it to Prometheus
+type Metrics struct {
+	executions *family
+	duration   *family
+	outputSize *family
+	inFlight   *family
+	timeouts   *family
+	oom        *family
+	cache      *family
+}
+
+// New creates an empty set of metrics
+func New() *Metrics {
+	return &Metrics{
+		executions: newFamily("mcp_executions_total", "Executions by language, tool and error type.", typeCounter,
+			[]string{"language", "tool", "error_type"}, nil),
+		duration: newFamily("mcp_execution_duration_seconds", "Execution time, including any build step.", typeHistogram,
+			[]string{"language", "tool"}, durationBuckets),
+		outputSize: newFamily("mcp_execution_output_bytes", "Size of the output of executions.", typeHistogram,
+			[]string{"language", "tool", "stream"}, sizeBuckets),
+		inFlight: newFamily("mcp_executions_in_flight", "Executions currently running.", typeGauge,
+			[]string{"language"}, nil),
+		timeouts: newFamily("mcp_execution_timeouts_total", "Executions stopped at their time limit.", typeCounter,
+			[]string{"language"}, nil),
+		oom: newFamily("mcp_execution_oom_total", "Executions that ran out of memory or were killed with SIGKILL.", typeCounter,
+			[]string{"language"}, nil),
+		cache: newFamily("mcp_cache_requests_total", "Lookups in the Go build cache and the Python worker pool, by result.", typeCounter,
+			[]string{"cache", "result"}, nil),
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, f := range []*family{m.executions, m.duration, m.outputSize, m.inFlight, m.timeouts, m.oom, m.cache} {
+			f.write(w)
+		}
+	})
+}
+
+// start counts an execution as running and returns the function that
+// records its result
+func (m *Metrics) start(req domain.ExecutionRequest) func(*domain.ExecutionResult, error) {
+	tool := req.Tool
+	if tool == "" {
+		tool = "none"
+	}
+	m.inFlight.add(1, req.Language)
+
+	return func(result *domain.ExecutionResult, err error) {
+		m.inFlight.add(-1, req.Language)
+		if err != nil || result == nil {
+			m.executions.add(1, req.Language, tool, "ExecutorError")
+			return
+		}
+
+		m.executions.add(1, req.Language, tool, result.ErrorType.String())
+		m.duration.observe(result.Duration.Seconds(), req.Language, tool)
+		m.outputSize.observe(float64(len(result.Stdout)), req.Language, tool, "stdout")
+		m.outputSize.observe(float64(len(result.Stderr)), req.Language, tool, "stderr")
+		if result.ErrorType == domain.TimeoutError {
+			m.timeouts.add(1, req.Language)
+		} else if result.OutOfMemory {
+			m.oom.add(1, req.Language)
+		}
+		if result.BuildCache != domain.CacheDisabled {
+			m.cache.add(1, "go_build", string(result.BuildCache))
+		}
+		if result.WorkerPool != domain.CacheDisabled {
+			m.cache.add(1, "python_pool", string(result.WorkerPool))
+		}
+	}
+}
+
+// Instrument wraps executor so that its executions are recorded. The
+// wrapper keeps the optional interfaces of executor: processes prepared
+// through ports.CommandPreparer, as pipelines use, are recorded when their
+// result is built, and ports.ModuleCatalog is passed through.
+func (m *Metrics) Instrument(executor ports.CodeExecutor) ports.CodeExecutor {
+	base := &instrumented{CodeExecutor: executor, metrics: m}
+	inner, canPrepare := executor.(ports.CommandPreparer)
+	catalog, hasCatalog := executor.(ports.ModuleCatalog)
+	prep := preparer{inner: inner, metrics: m}
+
+	switch {
+	case canPrepare && hasCatalog:
+		return &instrumentedPreparerCatalog{base, prep, catalog}
+	case canPrepare:
+		return &instrumentedPreparer{base, prep}
+	case hasCatalog:
+		return &instrumentedCatalog{base, catalog}
+	default:
+		return base
+	}
+}
+
+// instrumented records each call to Execute
+type instrumented struct {
+	ports.CodeExecutor
+	metrics *Metrics
+}
+
+// Execute runs req with the wrapped executor and records the outcome
+func (e *instrumented) Execute(ctx context.Context, req domain.ExecutionRequest) (*domain.ExecutionResult, error) {
+	done := e.metrics.start(req)
+	result, err := e.CodeExecutor.Execute(ctx, req)
+	done(result, err)
+	return result, err
+}
+
+// preparer records the processes the wrapped executor prepares
+type preparer struct {
+	inner   ports.CommandPreparer
+	metrics *Metrics
+}
+
+// Prepare prepares req with the wrapped executor. Rejections are recorded
+// at once; prepared processes when their result is built, and as
+// running until they are cleaned up.
+func (p preparer) Prepare(ctx context.Context, req domain.ExecutionRequest) (ports.PreparedProcess, *domain.ExecutionResult, error) {
+	done := p.metrics.start(req)
+	prepared, rejected, err := p.inner.Prepare(ctx, req)
+	if prepared == nil {
+		done(rejected, err)
+		return nil, rejected, err
+	}
+	return &preparedProcess{PreparedProcess: prepared, done: done}, nil, nil
+}
+
+// preparedProcess records the outcome of a prepared process when its
+// result is built
+type preparedProcess struct {
+	ports.PreparedProcess
+	done     func(*domain.ExecutionResult, error)
+	recorded bool
+}
+
+// Result builds the result with the wrapped process and records it
+func (p *preparedProcess) Result(err error, stdout, stderr string, duration time.Duration) *domain.ExecutionResult {
+	result := p.PreparedProcess.Result(err, stdout, stderr, duration)
+	p.done(result, nil)
+	p.recorded = true
+	return result
+}
+
+// Cleanup ends the recording of a process that never produced a result
+// and cleans up the wrapped process
+func (p *preparedProcess) Cleanup() {
+	if !p.recorded {
+		p.done(nil, nil)
+	}
+	p.PreparedProcess.Cleanup()
+}
+
+// The wrappers for each combination of optional interfaces
+type (
+	instrumentedPreparer struct {
+		*instrumented
+		preparer
+	}
+	instrumentedCatalog struct {
+		*instrumented
+		ports.ModuleCatalog
+	}
+	instrumentedPreparerCatalog struct {
+		*instrumented
+		preparer
+		ports.ModuleCatalog
+	}
+)
@@ -0,0 +1,316 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// Batching limits: spans are exported when this many are waiting or the
+// interval passes, and dropped when the queue is full
+const (
+	batchSize      = 256
+	batchInterval  = 5 * time.Second
+	queueSize      = 2048
+	exportTimeout  = 10 * time.Second
+	instrumentName = "github.com/aravi/code_execution_mcp"
+)
+
+// exporter sends encoded spans somewhere
+type exporter interface {
+	export(ctx context.Context, payload []byte) error
+	close() error
+}
+
+// Provider batches ended spans and exports them in the background
+type Provider struct {
+	serviceName string
+	exporter    exporter
+
+	queue chan *Span
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	// closeErr is the exporter's close error, set by run before done is
+	// closed
+	closeErr error
+
+	// closed is set by Shutdown under the write lock; senders hold the
+	// read lock, so none is still sending once the queue is drained. The
+	// queue itself is never closed, as a late span would panic on it.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewProvider creates a provider for cfg, or returns nil when tracing is
+// disabled
+func NewProvider(cfg config.TracingConfig) (*Provider, error) {
+	var exp exporter
+	switch cfg.Exporter {
+	case config.TracingNone:
+		return nil, nil
+	case config.TracingOTLP:
+		exp = newOTLPExporter(cfg.Endpoint, cfg.Headers)
+	case config.TracingFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		exp = &fileExporter{file: f}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	p := &Provider{
+		serviceName: cfg.ServiceName,
+		exporter:    exp,
+		queue:       make(chan *Span, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// enqueue queues an ended span, dropping it when the queue is full or the
+// provider has been shut down
+func (p *Provider) enqueue(s *Span) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.queue <- s:
+	default:
+	}
+}
+
+// run exports queued spans in batches until Shutdown is called, then
+// closes the exporter
+func (p *Provider) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := p.exporter.export(ctx, p.encode(batch)); err != nil {
+			slog.Error("Exporting spans failed", "spans", len(batch), "error", err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case s := <-p.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case s := <-p.queue:
+					batch = append(batch, s)
+					if len(batch) >= batchSize {
+						flush()
+					}
+				default:
+					flush()
+					p.closeErr = p.exporter.close()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown exports the spans that are still queued and closes the
+// exporter. Spans ended afterwards are dropped, and later calls return the
+// same error without closing the exporter again.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.once.Do(func() {
+		if provider.Load() == p {
+			provider.Store(nil)
+		}
+		p.mu.Lock()
+		p.closed = true
+		p.mu.Unlock()
+		close(p.stop)
+	})
+	select {
+	case <-p.done:
+		return p.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OTLP JSON encoding of spans
+type (
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// encode renders spans as an OTLP JSON traces document
+func (p *Provider) encode(spans []*Span) []byte {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.context.traceID[:]),
+			SpanID:            hex.EncodeToString(s.context.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attrs),
+			Status:            otlpStatus{Code: s.status, Message: s.message},
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	doc := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes([]Attribute{String("service.name", p.serviceName)})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentName}, Spans: encoded}},
+	}}}
+	payload, _ := json.Marshal(doc)
+	return payload
+}
+
+// encodeAttributes renders attributes as OTLP key-value pairs. OTLP JSON
+// carries 64-bit integers as strings.
+func encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, otlpAttribute{Key: a.Key, Value: value})
+	}
+	return encoded
+}
+
+// otlpExporter posts spans to an OTLP/HTTP collector
+type otlpExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// newOTLPExporter sends to the traces path below endpoint, unless
+// endpoint already names it
+func newOTLPExporter(endpoint string, headers map[string]string) *otlpExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &otlpExporter{url: url, headers: headers, client: &http.Client{}}
+}
+
+func (e *otlpExporter) export(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *otlpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// fileExporter appends one OTLP JSON document per line to a file, the
+// format the OpenTelemetry Collector's file receiver reads
+type fileExporter struct {
+	file *os.File
+}
+
+func (e *fileExporter) export(_ context.Context, payload []byte) error {
+	_, err := e.file.Write(append(payload, '\n'))
+	return err
+}
+
+func (e *fileExporter) close() error {
+	return e.file.Close()
+}
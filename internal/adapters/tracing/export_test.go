@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aravi/code_execution_mcp/internal/config"
+)
+
+// recordingExporter keeps the spans it is given
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans int
+}
+
+func (e *recordingExporter) export(_ context.Context, payload []byte) error {
+	var traces otlpTraces
+	if err := json.Unmarshal(payload, &traces); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, rs := range traces.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			e.spans += len(ss.Spans)
+		}
+	}
+	return nil
+}
+
+func (e *recordingExporter) close() error { return nil }
+
+func TestProviderShutdown(t *testing.T) {
+	exp := &recordingExporter{}
+	p := &Provider{
+		exporter: exp,
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+
+	for i := 0; i < 3; i++ {
+		p.enqueue(&Span{})
+	}
+
+	// Spans that end while and after the provider shuts down are dropped
+	// rather than sent on a closed queue
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.enqueue(&Span{})
+			}
+		}()
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	wg.Wait()
+	p.enqueue(&Span{})
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+
+	if exp.spans < 3 {
+		t.Errorf("exported %d spans, want at least the 3 queued before Shutdown", exp.spans)
+	}
+}
+
+func TestProviderShutdownClosesFileOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	p, err := NewProvider(config.TracingConfig{
+		Exporter:    config.TracingFile,
+		File:        path,
+		ServiceName: "test",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	p.enqueue(&Span{name: "call"})
+	for i := 0; i < 2; i++ {
+		if err := p.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown %d: %v", i+1, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading trace file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("trace file has %d lines, want 1", lines)
+	}
+}
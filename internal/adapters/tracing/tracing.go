@@ -0,0 +1,168 @@
+// Package tracing records OpenTelemetry spans and exports them in the
+// OTLP JSON encoding. Spans travel in contexts; when no provider is set,
+// starting a span only carries the trace context along.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Span kinds, as numbered by OTLP
+const (
+	kindInternal = 1
+	kindServer   = 2
+)
+
+// Span status codes, as numbered by OTLP
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// Attribute is a key and a string, integer or boolean value
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// spanContext identifies a span within its trace
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+// Span times one operation. A nil Span, as returned when tracing is off,
+// ignores every call.
+type Span struct {
+	provider *Provider
+	context  spanContext
+	parent   [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mu      sync.Mutex
+	end     time.Time
+	attrs   []Attribute
+	status  int
+	message string
+	ended   bool
+}
+
+type contextKey struct{}
+
+// provider is the provider spans are recorded with
+var provider atomic.Pointer[Provider]
+
+// SetProvider makes p record the spans started from now on
+func SetProvider(p *Provider) {
+	provider.Store(p)
+}
+
+// Start starts a span named name as a child of the span or remote parent
+// in ctx, and returns a context carrying it
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, kindInternal, attrs)
+}
+
+// StartServer starts a span for handling a request from a client
+func StartServer(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, kindServer, attrs)
+}
+
+func start(ctx context.Context, name string, kind int, attrs []Attribute) (context.Context, *Span) {
+	p := provider.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	span := &Span{provider: p, name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(contextKey{}).(spanContext); ok {
+		span.context.traceID = parent.traceID
+		span.parent = parent.spanID
+	} else {
+		rand.Read(span.context.traceID[:])
+	}
+	rand.Read(span.context.spanID[:])
+	return context.WithValue(ctx, contextKey{}, span.context), span
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks the span as failed with message
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.message = statusError, message
+}
+
+// End ends the span and hands it to the provider's exporter. Later calls
+// do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+	s.provider.enqueue(s)
+}
+
+// WithRemoteParent returns a context whose spans continue the trace named
+// by a W3C traceparent header. Malformed headers are ignored.
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx
+	}
+	var parent spanContext
+	if _, err := hex.Decode(parent.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(parent.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	if parent.traceID == [16]byte{} || parent.spanID == [8]byte{} {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, parent)
+}
+
+// TraceID returns the hex ID of the trace ctx belongs to, or "" outside
+// a trace
+func TraceID(ctx context.Context) string {
+	if sc, ok := ctx.Value(contextKey{}).(spanContext); ok {
+		return hex.EncodeToString(sc.traceID[:])
+	}
+	return ""
+}
@@ -0,0 +1,541 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// Config holds the server configuration
+type Config struct {
+	Golang    GolangConfig    `json:"golang"`
+	Python    PythonConfig    `json:"python"`
+	Batch     BatchConfig     `json:"batch"`
+	Benchmark BenchmarkConfig `json:"benchmark"`
+	Policy    PolicyConfig    `json:"policy"`
+	Redaction RedactionConfig `json:"redaction"`
+	Sandbox   SandboxConfig   `json:"sandbox"`
+	Metrics   MetricsConfig   `json:"metrics"`
+	Tracing   TracingConfig   `json:"tracing"`
+	Audit     AuditConfig     `json:"audit"`
+	Logging   LoggingConfig   `json:"logging"`
+	History   HistoryConfig   `json:"history"`
+
+	// Profiles are named overrides of Policy, selected with UseProfile.
+	// Each is a policy object applied on top of the base policy.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+}
+
+// MetricsConfig configures the admin listener that serves Prometheus
+// metrics at /metrics. An empty Listen address disables it.
+type MetricsConfig struct {
+	Listen string `json:"listen,omitempty"`
+}
+
+// TracingConfig configures the spans recorded for tool calls and
+// executions. Exporter is none, otlp, which posts OTLP JSON to the
+// collector at Endpoint with the extra Headers, or file, which appends
+// the same JSON to File, one batch per line.
+type TracingConfig struct {
+	Exporter    string            `json:"exporter"`
+	Endpoint    string            `json:"endpoint,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	File        string            `json:"file,omitempty"`
+	ServiceName string            `json:"service_name"`
+}
+
+// AuditConfig configures the audit log, a file of JSON lines recording
+// each tool call. An empty File disables it.
+type AuditConfig struct {
+	File string `json:"file,omitempty"`
+}
+
+// LoggingConfig configures the server's log, which is written to stderr
+// in Format (text or json) at Level (debug, info, warn or error) and
+// above. Clients that set a level with logging/setLevel also receive the
+// records at or above it.
+type LoggingConfig struct {
+	Format string `json:"format"`
+	Level  string `json:"level"`
+}
+
+// HistoryConfig configures the history of executions that clients can
+// read back as resources and re-run. The newest MaxEntries executions are
+// kept in memory and, when Dir is set, in files there, so that they
+// survive restarts. Clients see only their own session's executions
+// unless Shared is set, which also makes the executions saved before a
+// restart readable.
+type HistoryConfig struct {
+	Enabled    bool   `json:"enabled"`
+	MaxEntries int    `json:"max_entries,omitempty"`
+	Dir        string `json:"dir,omitempty"`
+	Shared     bool   `json:"shared,omitempty"`
+}
+
+// GolangConfig configures the Go executor
+type GolangConfig struct {
+	BuildCache BuildCacheConfig `json:"build_cache"`
+	Modules    ModulesConfig    `json:"modules"`
+}
+
+// BuildCacheConfig configures the compiled-binary cache for Go executions
+type BuildCacheConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Dir       string `json:"dir,omitempty"`
+	MaxSizeMB int64  `json:"max_size_mb,omitempty"`
+}
+
+// ModulesConfig controls module resolution for Go executions. GoCache and
+// GoModCache are shared by every execution. In offline mode modules are
+// only resolved from MirrorDir, a directory in GOPROXY file layout, or from
+// the module cache when no mirror is configured.
+type ModulesConfig struct {
+	GoCache    string `json:"gocache,omitempty"`
+	GoModCache string `json:"gomodcache,omitempty"`
+	Offline    bool   `json:"offline"`
+	MirrorDir  string `json:"mirror_dir,omitempty"`
+}
+
+// PythonConfig configures the Python executor
+type PythonConfig struct {
+	Pool PoolConfig `json:"pool"`
+}
+
+// PoolConfig configures the warm interpreter pool. Each worker serves a
+// single execution; idle workers older than RecycleAfterSeconds are
+// replaced so that long-lived processes do not accumulate state.
+type PoolConfig struct {
+	Enabled             bool     `json:"enabled"`
+	Size                int      `json:"size,omitempty"`
+	Preload             []string `json:"preload,omitempty"`
+	RecycleAfterSeconds int      `json:"recycle_after_seconds,omitempty"`
+}
+
+// BatchConfig configures the execute_batch tool
+type BatchConfig struct {
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	MaxItems       int `json:"max_items,omitempty"`
+}
+
+// BenchmarkConfig bounds the work a single benchmark_code call may do
+type BenchmarkConfig struct {
+	MaxRuns              int `json:"max_runs,omitempty"`
+	MaxTimeBudgetSeconds int `json:"max_time_budget_seconds,omitempty"`
+}
+
+// PolicyConfig holds the checks code must pass before it runs
+type PolicyConfig struct {
+	Shell    ShellPolicy    `json:"shell"`
+	Python   PythonPolicy   `json:"python"`
+	Go       GoPolicy       `json:"go"`
+	Approval ApprovalConfig `json:"approval"`
+}
+
+// ShellPolicy restricts what shell scripts may do. Scripts are parsed and
+// every command is checked, including those in pipelines, subshells,
+// command substitutions and literal bash -c or eval strings. Commands are
+// matched by base name. When AllowCommands is set, only those commands,
+// shell builtins and functions defined by the script may run. Write
+// checks only see paths that are literal in the script.
+type ShellPolicy struct {
+	Enabled                bool     `json:"enabled"`
+	DenyCommands           []string `json:"deny_commands,omitempty"`
+	AllowCommands          []string `json:"allow_commands,omitempty"`
+	DenyRemoteScripts      bool     `json:"deny_remote_scripts"`
+	DenyDestructiveDeletes bool     `json:"deny_destructive_deletes"`
+	RestrictWrites         bool     `json:"restrict_writes"`
+	WritableDirs           []string `json:"writable_dirs,omitempty"`
+}
+
+// PythonPolicy restricts what Python code may import and call. Code is
+// parsed with Python's ast module before it runs. Module names match
+// themselves and their submodules. DenyCalls holds dotted names, such as
+// eval or os.system, resolved through import aliases; any use of them is
+// flagged, not only calls. When AllowImports is set, only those modules
+// may be imported.
+type PythonPolicy struct {
+	Enabled      bool     `json:"enabled"`
+	DenyImports  []string `json:"deny_imports,omitempty"`
+	AllowImports []string `json:"allow_imports,omitempty"`
+	DenyCalls    []string `json:"deny_calls,omitempty"`
+}
+
+// GoPolicy restricts what Go code may import. Code and test code are
+// parsed with go/parser before they are built. Import paths match
+// themselves and the packages below them. StdlibOnly rejects imports
+// whose first path element has no dot, the rule the go command uses to
+// tell standard library packages from modules. DenyLinkname rejects
+// //go:linkname directives and DenyCgo rejects import "C".
+type GoPolicy struct {
+	Enabled      bool     `json:"enabled"`
+	DenyImports  []string `json:"deny_imports,omitempty"`
+	AllowImports []string `json:"allow_imports,omitempty"`
+	StdlibOnly   bool     `json:"stdlib_only"`
+	DenyLinkname bool     `json:"deny_linkname"`
+	DenyCgo      bool     `json:"deny_cgo"`
+}
+
+// ApprovalConfig controls when a human must approve an execution. Requests
+// whose risk reaches Threshold (low, medium or high) are shown to the
+// client's user through MCP elicitation. When the client cannot ask, or
+// the user does not answer within WaitSeconds, Fallback (deny or allow)
+// decides. Timeouts above LongTimeoutSeconds count as medium risk, and
+// writes under WritableDirs count as inside the workspace.
+type ApprovalConfig struct {
+	Enabled            bool     `json:"enabled"`
+	Threshold          string   `json:"threshold"`
+	Fallback           string   `json:"fallback"`
+	WaitSeconds        int      `json:"wait_seconds"`
+	LongTimeoutSeconds int      `json:"long_timeout_seconds"`
+	WritableDirs       []string `json:"writable_dirs,omitempty"`
+}
+
+// RedactionConfig controls the masking of secrets in execution output.
+// Builtins names the built-in patterns to apply and Patterns adds named
+// regular expressions. SecretEnv lists the server environment variables
+// whose values are masked wherever they appear; names may use *
+// wildcards. Tokens of at least MinEntropyLength characters with
+// MinEntropyBits of entropy per character are masked as high_entropy;
+// zero MinEntropyBits turns that check off.
+type RedactionConfig struct {
+	Enabled          bool               `json:"enabled"`
+	Builtins         []string           `json:"builtins"`
+	Patterns         []RedactionPattern `json:"patterns,omitempty"`
+	SecretEnv        []string           `json:"secret_env,omitempty"`
+	MinEntropyBits   float64            `json:"min_entropy_bits"`
+	MinEntropyLength int                `json:"min_entropy_length"`
+}
+
+// RedactionPattern is a regular expression whose matches are masked with
+// a placeholder naming it
+type RedactionPattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"regex"`
+}
+
+// SandboxConfig confines the processes that run submitted code
+type SandboxConfig struct {
+	Network    NetworkConfig    `json:"network"`
+	Filesystem FilesystemConfig `json:"filesystem"`
+	Seccomp    SeccompConfig    `json:"seccomp"`
+	User       UserConfig       `json:"user"`
+}
+
+// NetworkConfig controls what executed code may reach. DefaultMode (none,
+// loopback, allowlist or host) applies to requests that don't pick a
+// mode, and requests may only pick a stricter one. Allowlist holds the
+// destinations the allowlist mode's proxy connects to, as host,
+// host:port or *.domain.
+type NetworkConfig struct {
+	DefaultMode string   `json:"default_mode"`
+	Allowlist   []string `json:"allowlist,omitempty"`
+}
+
+// FilesystemConfig controls what executed code may read and write. With
+// Backend "landlock", processes may only read and execute ReadOnly paths
+// and the directory of the program they run, and may write to ReadWrite
+// paths, their working directory and the temp directory. Backend "none"
+// leaves the filesystem alone.
+type FilesystemConfig struct {
+	Backend   string   `json:"backend"`
+	ReadOnly  []string `json:"read_only,omitempty"`
+	ReadWrite []string `json:"read_write,omitempty"`
+}
+
+// SeccompConfig selects the system call filter installed on executed
+// processes. Profile is none, one of the built-in profiles (default,
+// strict or compute-only), or the path of a profile in the OCI (Docker)
+// seccomp format.
+type SeccompConfig struct {
+	Profile string `json:"profile"`
+}
+
+// UserConfig selects the account executed processes run as. UID and GID
+// run every process as that user and group; zero keeps the server's own,
+// and GID defaults to UID. UIDPool instead gives each client session its
+// own UID. PrivateHome points HOME at a fresh directory for each
+// execution, and Umask is the octal file creation mask, such as "077",
+// with empty keeping the server's. The server refuses to start as root
+// unless processes run as another user or AllowRoot is set.
+type UserConfig struct {
+	UID         int           `json:"uid"`
+	GID         int           `json:"gid"`
+	UIDPool     UIDPoolConfig `json:"uid_pool"`
+	PrivateHome bool          `json:"private_home"`
+	Umask       string        `json:"umask,omitempty"`
+	AllowRoot   bool          `json:"allow_root"`
+}
+
+// UIDPoolConfig is the range of Size UIDs beginning at Start that are
+// handed out to client sessions
+type UIDPoolConfig struct {
+	Start int `json:"start"`
+	Size  int `json:"size"`
+}
+
+// Filesystem sandbox backends
+const (
+	FilesystemNone     = "none"
+	FilesystemLandlock = "landlock"
+)
+
+// Trace exporters
+const (
+	TracingNone = "none"
+	TracingOTLP = "otlp"
+	TracingFile = "file"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Approval fallbacks
+const (
+	FallbackDeny  = "deny"
+	FallbackAllow = "allow"
+)
+
+// Default returns the configuration used when no config file is given
+func Default() *Config {
+	return &Config{
+		Golang: GolangConfig{
+			BuildCache: BuildCacheConfig{
+				Enabled:   true,
+				MaxSizeMB: 512,
+			},
+		},
+		Python: PythonConfig{
+			Pool: PoolConfig{
+				Size:                2,
+				RecycleAfterSeconds: 600,
+			},
+		},
+		Batch: BatchConfig{
+			MaxConcurrency: 4,
+			MaxItems:       20,
+		},
+		Benchmark: BenchmarkConfig{
+			MaxRuns:              100,
+			MaxTimeBudgetSeconds: 120,
+		},
+		Redaction: RedactionConfig{
+			Enabled:          true,
+			Builtins:         []string{"aws_access_key", "github_token", "private_key", "jwt"},
+			SecretEnv:        []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*API_KEY*", "*ACCESS_KEY*"},
+			MinEntropyBits:   4.5,
+			MinEntropyLength: 32,
+		},
+		Sandbox: SandboxConfig{
+			Network: NetworkConfig{DefaultMode: "host"},
+			Filesystem: FilesystemConfig{
+				Backend:   FilesystemNone,
+				ReadOnly:  []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt", "/proc", "/sys"},
+				ReadWrite: []string{"/dev"},
+			},
+			Seccomp: SeccompConfig{Profile: "none"},
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingNone,
+			ServiceName: "code-execution-mcp",
+		},
+		Logging: LoggingConfig{
+			Format: LogFormatText,
+			Level:  "info",
+		},
+		History: HistoryConfig{
+			Enabled:    true,
+			MaxEntries: 100,
+		},
+		Policy: PolicyConfig{
+			Shell: ShellPolicy{
+				Enabled:                true,
+				DenyCommands:           []string{"sudo", "su", "doas", "shutdown", "reboot", "poweroff", "halt"},
+				DenyRemoteScripts:      true,
+				DenyDestructiveDeletes: true,
+			},
+			Python: PythonPolicy{
+				DenyImports: []string{"ctypes", "subprocess", "pty"},
+				DenyCalls:   []string{"eval", "exec", "compile", "__import__", "importlib.import_module", "os.system", "os.popen"},
+			},
+			Go: GoPolicy{
+				DenyImports:  []string{"os/exec", "syscall", "unsafe", "net", "plugin"},
+				StdlibOnly:   true,
+				DenyLinkname: true,
+				DenyCgo:      true,
+			},
+			Approval: ApprovalConfig{
+				Threshold:          "medium",
+				Fallback:           FallbackDeny,
+				WaitSeconds:        300,
+				LongTimeoutSeconds: 120,
+			},
+		},
+	}
+}
+
+// Load reads a JSON configuration file on top of the defaults.
+// An empty path returns the defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// UseProfile replaces the policy with the named profile applied on top of
+// it. An empty name keeps the base policy.
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	raw, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	// Round-trip the base policy so the profile cannot alias its slices
+	base, err := json.Marshal(c.Policy)
+	if err != nil {
+		return err
+	}
+	var policy PolicyConfig
+	if err := json.Unmarshal(base, &policy); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return fmt.Errorf("parsing profile %q: %w", name, err)
+	}
+	c.Policy = policy
+	if err := c.validate(); err != nil {
+		return fmt.Errorf("invalid profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// validate checks the settings that take a fixed set of values
+func (c *Config) validate() error {
+	switch c.Policy.Approval.Threshold {
+	case "low", "medium", "high":
+	default:
+		return fmt.Errorf("unknown approval threshold %q (use low, medium or high)", c.Policy.Approval.Threshold)
+	}
+	switch c.Policy.Approval.Fallback {
+	case FallbackDeny, FallbackAllow:
+	default:
+		return fmt.Errorf("unknown approval fallback %q (use %s or %s)", c.Policy.Approval.Fallback, FallbackDeny, FallbackAllow)
+	}
+	switch c.Sandbox.Network.DefaultMode {
+	case "none", "loopback", "allowlist", "host":
+	default:
+		return fmt.Errorf("unknown network mode %q (use none, loopback, allowlist or host)", c.Sandbox.Network.DefaultMode)
+	}
+	switch c.Sandbox.Filesystem.Backend {
+	case FilesystemNone, FilesystemLandlock:
+	default:
+		return fmt.Errorf("unknown filesystem sandbox backend %q (use %s or %s)", c.Sandbox.Filesystem.Backend, FilesystemNone, FilesystemLandlock)
+	}
+	if c.Sandbox.Seccomp.Profile == "" {
+		return fmt.Errorf("seccomp profile must not be empty (use none to disable filtering)")
+	}
+	if err := c.Sandbox.User.validate(); err != nil {
+		return err
+	}
+	switch c.Tracing.Exporter {
+	case TracingNone:
+	case TracingOTLP:
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("the otlp trace exporter needs an endpoint")
+		}
+	case TracingFile:
+		if c.Tracing.File == "" {
+			return fmt.Errorf("the file trace exporter needs a file")
+		}
+	default:
+		return fmt.Errorf("unknown trace exporter %q (use %s, %s or %s)", c.Tracing.Exporter, TracingNone, TracingOTLP, TracingFile)
+	}
+	switch c.Logging.Format {
+	case LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q (use %s or %s)", c.Logging.Format, LogFormatText, LogFormatJSON)
+	}
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("unknown log level %q (use debug, info, warn or error)", c.Logging.Level)
+	}
+	for _, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern.Regex); err != nil {
+			return fmt.Errorf("redaction pattern %q: %w", pattern.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the user and group IDs and the umask
+func (u UserConfig) validate() error {
+	if u.UID < 0 || u.GID < 0 {
+		return fmt.Errorf("sandbox user IDs must not be negative")
+	}
+	if u.UIDPool.Size < 0 || u.UIDPool.Size > 0 && u.UIDPool.Start <= 0 {
+		return fmt.Errorf("sandbox UID pool needs a positive start and size")
+	}
+	if u.UID != 0 && u.UIDPool.Size > 0 {
+		return fmt.Errorf("set either a sandbox uid or a uid_pool, not both")
+	}
+	if _, err := u.ParseUmask(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseUmask returns the configured umask, or -1 when none is set
+func (u UserConfig) ParseUmask() (int, error) {
+	if u.Umask == "" {
+		return -1, nil
+	}
+	mask, err := strconv.ParseUint(u.Umask, 8, 32)
+	if err != nil || mask > 0o777 {
+		return 0, fmt.Errorf("invalid umask %q (use an octal mode such as 077)", u.Umask)
+	}
+	return int(mask), nil
+}
+
+// applyDefaults fills in values that depend on the host environment
+func (c *Config) applyDefaults() {
+	if c.Golang.BuildCache.Dir == "" {
+		c.Golang.BuildCache.Dir = cacheDir("go-bin")
+	}
+	if c.Golang.Modules.GoCache == "" {
+		c.Golang.Modules.GoCache = cacheDir("gocache")
+	}
+	if c.Golang.Modules.GoModCache == "" {
+		c.Golang.Modules.GoModCache = cacheDir("gomodcache")
+	}
+}
+
+// cacheDir returns a per-user cache directory for the server
+func cacheDir(name string) string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "code-execution-mcp", name)
+}
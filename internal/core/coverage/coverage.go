@@ -0,0 +1,158 @@
+// Package coverage turns line execution data into per-file line coverage
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// FromLines builds the coverage of path from its executable lines and the
+// lines that ran. Executed lines that are not executable are ignored.
+func FromLines(path string, executable, executed []int) domain.FileCoverage {
+	ran := make(map[int]bool, len(executed))
+	for _, line := range executed {
+		ran[line] = true
+	}
+	lines := append([]int{}, executable...)
+	sort.Ints(lines)
+
+	file := domain.FileCoverage{Path: path}
+	for i, line := range lines {
+		if i > 0 && line == lines[i-1] {
+			continue
+		}
+		file.Lines++
+		if ran[line] {
+			file.Covered++
+			continue
+		}
+		if n := len(file.Uncovered); n > 0 && file.Uncovered[n-1].End == line-1 {
+			file.Uncovered[n-1].End = line
+		} else {
+			file.Uncovered = append(file.Uncovered, domain.LineRange{Start: line, End: line})
+		}
+	}
+	return file
+}
+
+// ParseGoProfile reads a cover profile in the text format written by
+// `go test -coverprofile` and `go tool covdata textfmt`. Go profiles count
+// blocks rather than lines, so every line a block spans is attributed to
+// it, except for lines in sources that hold no code. prefix is trimmed
+// from file names, and sources is keyed by the trimmed names.
+func ParseGoProfile(profile []byte, prefix string, sources map[string][]byte) ([]domain.FileCoverage, error) {
+	executable := map[string]map[int]bool{}
+	executed := map[string]map[int]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(profile))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		name, start, end, count, err := parseBlock(line)
+		if err != nil {
+			return nil, fmt.Errorf("cover profile line %d: %w", lineNo, err)
+		}
+		name = strings.TrimPrefix(name, prefix)
+		if executable[name] == nil {
+			executable[name] = map[int]bool{}
+			executed[name] = map[int]bool{}
+		}
+		source := sourceLines(sources[name])
+		for l := start; l <= end; l++ {
+			if source != nil && l <= len(source) && !hasCode(source[l-1]) {
+				continue
+			}
+			executable[name][l] = true
+			if count > 0 {
+				executed[name][l] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(executable))
+	for name := range executable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]domain.FileCoverage, 0, len(names))
+	for _, name := range names {
+		files = append(files, FromLines(name, keys(executable[name]), keys(executed[name])))
+	}
+	return files, nil
+}
+
+// parseBlock splits a profile line of the form
+// "file:startLine.startCol,endLine.endCol statements count"
+func parseBlock(line string) (name string, start, end int, count int64, err error) {
+	colon := strings.LastIndex(line, ":")
+	if colon < 0 {
+		return "", 0, 0, 0, fmt.Errorf("missing file name")
+	}
+	fields := strings.Fields(line[colon+1:])
+	if len(fields) != 3 {
+		return "", 0, 0, 0, fmt.Errorf("expected 3 fields, got %d", len(fields))
+	}
+	from, to, ok := strings.Cut(fields[0], ",")
+	if !ok {
+		return "", 0, 0, 0, fmt.Errorf("malformed block %q", fields[0])
+	}
+	if start, err = blockLine(from); err != nil {
+		return "", 0, 0, 0, err
+	}
+	if end, err = blockLine(to); err != nil {
+		return "", 0, 0, 0, err
+	}
+	if count, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		return "", 0, 0, 0, fmt.Errorf("malformed count %q", fields[2])
+	}
+	return line[:colon], start, end, count, nil
+}
+
+// blockLine returns the line of a "line.column" position
+func blockLine(position string) (int, error) {
+	line, _, _ := strings.Cut(position, ".")
+	n, err := strconv.Atoi(line)
+	if err != nil {
+		return 0, fmt.Errorf("malformed position %q", position)
+	}
+	return n, nil
+}
+
+// sourceLines splits source into lines, or returns nil when it is absent
+func sourceLines(source []byte) []string {
+	if source == nil {
+		return nil
+	}
+	return strings.Split(string(source), "\n")
+}
+
+// hasCode reports whether a source line holds more than blanks, braces
+// and a line comment
+func hasCode(line string) bool {
+	line = strings.TrimSpace(line)
+	if i := strings.Index(line, "//"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	return strings.Trim(line, "{}() \t") != ""
+}
+
+// keys returns the members of a line set
+func keys(set map[int]bool) []int {
+	lines := make([]int, 0, len(set))
+	for line := range set {
+		lines = append(lines, line)
+	}
+	return lines
+}
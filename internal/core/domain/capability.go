@@ -0,0 +1,33 @@
+package domain
+
+// Capability describes a confinement feature: whether the host supports
+// it, whether the server is configured to use it, and what was found when
+// probing for it
+type Capability struct {
+	Name      string
+	Available bool
+	Enabled   bool
+	Detail    string
+}
+
+// Toolchain describes a program that executed code runs with: where it was
+// found and which version it is, or why it was not found
+type Toolchain struct {
+	// Language is the language the toolchain runs
+	Language  string
+	Name      string
+	Path      string
+	Version   string
+	Available bool
+	Detail    string
+
+	// Optional toolchains are reported, but no tool depends on them
+	Optional bool
+
+	// Packages maps packages of interest to their installed version, or
+	// to "" when they are not installed
+	Packages map[string]string
+
+	// Env holds settings of the toolchain, such as the Go environment
+	Env map[string]string
+}
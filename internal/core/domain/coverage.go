@@ -0,0 +1,30 @@
+package domain
+
+// CoverageReport lists which lines of the executed code ran
+type CoverageReport struct {
+	Tool  string
+	Files []FileCoverage
+}
+
+// FileCoverage is the line coverage of one source file. Lines counts the
+// executable lines and Uncovered holds the executable lines that never ran.
+type FileCoverage struct {
+	Path      string
+	Lines     int
+	Covered   int
+	Uncovered []LineRange
+}
+
+// Percent returns the share of executable lines that ran
+func (f FileCoverage) Percent() float64 {
+	if f.Lines == 0 {
+		return 100
+	}
+	return 100 * float64(f.Covered) / float64(f.Lines)
+}
+
+// LineRange is an inclusive range of line numbers
+type LineRange struct {
+	Start int
+	End   int
+}
@@ -8,10 +8,39 @@ type ExecutionRequest struct {
 	Code       string
 	Script     string
 	Args       []string
+	Stdin      string
 	WorkingDir string
 	Timeout    int
+
+	// Mode selects how Go code is run: ModeRun (the default) builds and
+	// runs Code as a program, ModeTest runs the tests in TestCode against it
+	Mode     string
+	TestCode string
+
+	// Profile asks the executor to run the code under its profiler
+	Profile bool
+
+	// Coverage asks the executor to record which lines of the code ran
+	Coverage bool
+
+	// Network selects what the process may reach; empty means the
+	// server's default mode
+	Network string
+
+	// Session identifies the client session the request came from, so
+	// that the sandbox can give each session its own user
+	Session string
+
+	// Tool names the MCP tool the request came from
+	Tool string
 }
 
+// Execution modes for compiled languages
+const (
+	ModeRun  = "run"
+	ModeTest = "test"
+)
+
 // ExecutionResult represents the result of code execution
 type ExecutionResult struct {
 	ExitCode  int
@@ -20,8 +49,75 @@ type ExecutionResult struct {
 	Duration  time.Duration
 	IsError   bool
 	ErrorType ExecutionErrorType
+
+	// CompileDuration and RunDuration split Duration for compiled languages
+	CompileDuration time.Duration
+	RunDuration     time.Duration
+	BuildCache      CacheStatus
+
+	// WorkerPool reports whether a pre-started interpreter served the execution
+	WorkerPool CacheStatus
+
+	// CPUTime and PeakMemoryKB are the resource usage of the run, where the
+	// platform reports them
+	CPUTime      time.Duration
+	PeakMemoryKB int64
+
+	// Signal names the signal that killed the process, such as "killed",
+	// where the platform reports it
+	Signal string
+
+	// OutOfMemory is set when the process ran out of memory: its runtime
+	// reported a failed allocation, or it was killed with SIGKILL that the
+	// server did not send, as the kernel's OOM killer does
+	OutOfMemory bool
+
+	// Profile and Artifacts are set for profiled executions
+	Profile   *Profile
+	Artifacts []Artifact
+
+	// Coverage is set for executions run with coverage enabled
+	Coverage *CoverageReport
+
+	// Violations lists the policy rules a rejected request broke
+	Violations []PolicyViolation
+
+	// Redactions counts the secrets masked in the output, by category
+	Redactions map[string]int
+
+	// Connections lists the connections the process tried to make through
+	// the egress proxy
+	Connections []Connection
+
+	// BlockedSyscalls names the system calls the seccomp filter denied
+	BlockedSyscalls []string
+}
+
+// PipelineResult represents the result of executions connected by pipes.
+// Stages hold each stage's exit code and stderr; only the final stage's
+// stdout is captured, in Stdout. The exit code follows pipefail semantics:
+// it is that of the last stage to exit non-zero.
+type PipelineResult struct {
+	Stages    []*ExecutionResult
+	ExitCode  int
+	Stdout    string
+	Duration  time.Duration
+	IsError   bool
+	ErrorType ExecutionErrorType
+
+	// Redactions counts the secrets masked in the output, by category
+	Redactions map[string]int
 }
 
+// CacheStatus reports whether a cached artifact served an execution
+type CacheStatus string
+
+const (
+	CacheDisabled CacheStatus = ""
+	CacheHit      CacheStatus = "hit"
+	CacheMiss     CacheStatus = "miss"
+)
+
 // ExecutionErrorType categorizes execution errors
 type ExecutionErrorType int
 
@@ -31,6 +127,8 @@ const (
 	TimeoutError
 	RuntimeError
 	SystemError
+	SyscallBlockedError
+	CompilationError
 )
 
 // String returns the string representation of ExecutionErrorType
@@ -46,6 +144,10 @@ func (e ExecutionErrorType) String() string {
 		return "RuntimeError"
 	case SystemError:
 		return "SystemError"
+	case SyscallBlockedError:
+		return "SyscallBlockedError"
+	case CompilationError:
+		return "CompilationError"
 	default:
 		return "UnknownError"
 	}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// HistoryEntry is a past execution: the request as it ran and its result,
+// with the output as the client saw it
+type HistoryEntry struct {
+	ID      string
+	Time    time.Time
+	Request ExecutionRequest
+	Result  *ExecutionResult
+
+	// RerunOf is the ID of the entry this execution re-ran, if any
+	RerunOf string
+}
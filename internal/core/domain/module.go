@@ -0,0 +1,7 @@
+package domain
+
+// Module describes a Go module that executions can import
+type Module struct {
+	Path     string
+	Versions []string
+}
@@ -0,0 +1,27 @@
+package domain
+
+// Network modes, from least to most permissive
+const (
+	// NetworkNone gives the process no network interfaces at all
+	NetworkNone = "none"
+
+	// NetworkLoopback gives the process a private loopback interface,
+	// so it can talk to servers it starts itself but nothing else
+	NetworkLoopback = "loopback"
+
+	// NetworkAllowlist adds an HTTP(S) proxy that only connects to the
+	// allowed hosts
+	NetworkAllowlist = "allowlist"
+
+	// NetworkHost shares the server's network without restriction
+	NetworkHost = "host"
+)
+
+// Connection is one connection attempt made through the egress proxy.
+// Error is set when an allowed connection could not be established.
+type Connection struct {
+	Host    string
+	Port    string
+	Allowed bool
+	Error   string
+}
@@ -0,0 +1,11 @@
+package domain
+
+// PolicyViolation is a rule broken by submitted code, found before it ran.
+// Line and Column locate the offending node and are 1-based; they are zero
+// when the violation is not tied to a position.
+type PolicyViolation struct {
+	Rule    string
+	Message string
+	Line    int
+	Column  int
+}
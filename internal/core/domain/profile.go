@@ -0,0 +1,25 @@
+package domain
+
+// Profile summarizes where a profiled execution spent its time. Each table
+// lists the hottest functions of one profile, such as CPU or allocations.
+type Profile struct {
+	Tool   string
+	Tables []ProfileTable
+
+	// Warning explains a profile that could not be collected in full
+	Warning string
+}
+
+// ProfileTable is a top-N listing in the profiler's own columns
+type ProfileTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Artifact is a file produced by an execution, such as a raw profile
+type Artifact struct {
+	Name     string
+	MIMEType string
+	Data     []byte
+}
@@ -0,0 +1,95 @@
+package judge
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Comparator names accepted by Compare
+const (
+	Exact      = "exact"
+	Whitespace = "whitespace"
+	Float      = "float"
+	Regex      = "regex"
+)
+
+// DefaultTolerance is used by the float comparator when none is given
+const DefaultTolerance = 1e-6
+
+// Compare reports whether actual output matches expected under the named
+// comparator. An empty name means Exact.
+//
+//   - exact: identical after normalizing line endings and trailing newlines
+//   - whitespace: identical sequence of whitespace-separated tokens
+//   - float: like whitespace, but numeric tokens may differ by tolerance,
+//     absolute for small values and relative for large ones
+//   - regex: expected is a regular expression that must match the whole
+//     output
+func Compare(comparator, expected, actual string, tolerance float64) (bool, error) {
+	switch comparator {
+	case "", Exact:
+		return normalize(expected) == normalize(actual), nil
+	case Whitespace:
+		return equalTokens(strings.Fields(expected), strings.Fields(actual), nil), nil
+	case Float:
+		if tolerance <= 0 {
+			tolerance = DefaultTolerance
+		}
+		return equalTokens(strings.Fields(expected), strings.Fields(actual), func(e, a string) bool {
+			return floatsClose(e, a, tolerance)
+		}), nil
+	case Regex:
+		re, err := regexp.Compile(`(?s)\A(?:` + expected + `)\z`)
+		if err != nil {
+			return false, fmt.Errorf("invalid expected regex: %w", err)
+		}
+		return re.MatchString(normalize(actual)), nil
+	default:
+		return false, fmt.Errorf("unknown comparator %q (use exact, whitespace, float or regex)", comparator)
+	}
+}
+
+// normalize converts CRLF line endings and drops trailing newlines
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.TrimRight(text, "\n")
+}
+
+// equalTokens compares token lists, using match for tokens that are not
+// identical when it is non-nil
+func equalTokens(expected, actual []string, match func(e, a string) bool) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if expected[i] == actual[i] {
+			continue
+		}
+		if match == nil || !match(expected[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// floatsClose reports whether two numeric tokens are within tolerance
+func floatsClose(expected, actual string, tolerance float64) bool {
+	e, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	a, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+	if math.IsNaN(e) || math.IsNaN(a) {
+		return math.IsNaN(e) && math.IsNaN(a)
+	}
+	if math.IsInf(e, 0) || math.IsInf(a, 0) {
+		return e == a
+	}
+	return math.Abs(e-a) <= tolerance*math.Max(1, math.Abs(e))
+}
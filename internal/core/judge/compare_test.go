@@ -0,0 +1,78 @@
+package judge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		comparator string
+		expected   string
+		actual     string
+		tolerance  float64
+		want       bool
+	}{
+		{"", "hello", "hello\n", 0, true},
+		{Exact, "a\nb\n", "a\r\nb\r\n\n", 0, true},
+		{Exact, "hello", "hello ", 0, false},
+		{Exact, "a\nb", "a\n\nb", 0, false},
+		{Exact, "", "\n\n", 0, true},
+		{Exact, "Hello", "hello", 0, false},
+
+		{Whitespace, "1 2 3", "1\n2\t 3\n", 0, true},
+		{Whitespace, "  a b ", "a b", 0, true},
+		{Whitespace, "1 2 3", "1 2", 0, false},
+		{Whitespace, "1 2", "1 2 3", 0, false},
+		{Whitespace, "1.0", "1", 0, false},
+
+		{Float, "3.14159265", "3.1415927", 0, true},
+		{Float, "3.14159", "3.1416", 0, false},
+		{Float, "3.14159", "3.1416", 1e-4, true},
+		{Float, "0.000001", "0.0000015", 0, true},
+		{Float, "1000000", "1000000.5", 0, true},
+		{Float, "1000000", "1000002", 0, false},
+		{Float, "1e6", "1000000", 0, true},
+		{Float, "-2.5", "-2.5000001", 0, true},
+		{Float, "x = 1.0", "x = 1.0000001", 0, true},
+		{Float, "x = 1.0", "y = 1.0", 0, false},
+		{Float, "NaN", "nan", 0, true},
+		{Float, "NaN", "1", 0, false},
+		{Float, "Inf", "+Inf", 0, true},
+		{Float, "Inf", "-Inf", 0, false},
+		{Float, "1 2", "1", 0, false},
+
+		{Regex, `\d+`, "42\n", 0, true},
+		{Regex, `\d+`, "42 apples", 0, false},
+		{Regex, `hello|bye`, "bye", 0, true},
+		{Regex, `a.*z`, "a\nz", 0, true},
+		{Regex, `Result: [0-9.]+`, "Result: 3.5\r\n", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.comparator, tt.expected, tt.actual, tt.tolerance)
+		if err != nil {
+			t.Errorf("Compare(%q, %q, %q, %g): %v", tt.comparator, tt.expected, tt.actual, tt.tolerance, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Compare(%q, %q, %q, %g) = %v, want %v", tt.comparator, tt.expected, tt.actual, tt.tolerance, got, tt.want)
+		}
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	tests := []struct {
+		comparator string
+		expected   string
+		message    string
+	}{
+		{Regex, "(", "invalid expected regex"},
+		{"fuzzy", "x", `unknown comparator "fuzzy"`},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.comparator, tt.expected, "x", 0)
+		if err == nil || got || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Compare(%q, %q) = %v, %v; want an error containing %q", tt.comparator, tt.expected, got, err, tt.message)
+		}
+	}
+}
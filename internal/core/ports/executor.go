@@ -2,6 +2,8 @@ package ports
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/aravi/code_execution_mcp/internal/core/domain"
 )
@@ -14,3 +16,77 @@ type CodeExecutor interface {
 	// Supports checks if this executor supports the given language
 	Supports(language string) bool
 }
+
+// ModuleCatalog is implemented by executors that can list the third-party
+// modules available to executed code
+type ModuleCatalog interface {
+	// ListModules returns the available modules sorted by path
+	ListModules(ctx context.Context) ([]domain.Module, error)
+}
+
+// PreparedProcess is a process that is ready to start. Callers connect
+// its standard streams, start it, wait for it, build its result, and then
+// call Cleanup.
+type PreparedProcess interface {
+	// Context is the context the process runs under; it carries the
+	// timeout
+	Context() context.Context
+
+	// Connect sets the standard streams of the process; nil streams are
+	// left unconnected
+	Connect(stdin io.Reader, stdout, stderr io.Writer)
+
+	// Start starts the process inside the confinement the executor set up
+	// for it
+	Start() error
+
+	// Wait waits for the started process to exit
+	Wait() error
+
+	// Kill stops the started process and waits for it
+	Kill()
+
+	// Result builds the result of the process from the error Wait
+	// returned, its output and how long it ran, and attaches what the
+	// process left behind, such as a profile
+	Result(err error, stdout, stderr string, duration time.Duration) *domain.ExecutionResult
+
+	// Cleanup releases the timeout and any temporary files
+	Cleanup()
+}
+
+// CommandPreparer is implemented by executors that can hand back a process
+// instead of running it, so that callers can connect processes together
+type CommandPreparer interface {
+	// Prepare builds the process for req. When the request is rejected
+	// (for example by validation or a failed compile) it returns a nil
+	// process and the result to report instead.
+	Prepare(ctx context.Context, req domain.ExecutionRequest) (PreparedProcess, *domain.ExecutionResult, error)
+}
+
+// PipelineRunner runs executions connected by pipes
+type PipelineRunner interface {
+	// RunPipeline runs the stages concurrently, feeding each stage's
+	// stdout into the next stage's stdin
+	RunPipeline(ctx context.Context, stages []domain.ExecutionRequest) (*domain.PipelineResult, error)
+}
+
+// CapabilityReporter reports the confinement features the server probed
+// for at startup
+type CapabilityReporter interface {
+	// Capabilities returns each feature in a stable order
+	Capabilities() []domain.Capability
+}
+
+// SessionReleaser is implemented by sandboxes that hold resources for
+// each client session, such as a user to run its executions as
+type SessionReleaser interface {
+	// ReleaseSession frees what a closed session held
+	ReleaseSession(session string) error
+}
+
+// ToolchainReporter reports the toolchains the server found at startup
+type ToolchainReporter interface {
+	// Toolchains returns each toolchain in a stable order
+	Toolchains() []domain.Toolchain
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "github.com/aravi/code_execution_mcp/internal/core/domain"
+
+// ExecutionHistory keeps past executions so that clients can look at
+// them again and re-run them
+type ExecutionHistory interface {
+	// Record stores entry under a new ID and returns it
+	Record(entry domain.HistoryEntry) (domain.HistoryEntry, error)
+
+	// Get returns the entry with id
+	Get(id string) (domain.HistoryEntry, bool)
+
+	// List returns the stored entries, newest first
+	List() []domain.HistoryEntry
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Observer records the steps of executions as trace spans and log
+// records, so that executors need not know where either ends up
+type Observer interface {
+	// StartSpan starts a span named name as a child of the span in ctx
+	StartSpan(ctx context.Context, name string, attrs ...slog.Attr) Span
+
+	// Logger returns the logger of the request ctx belongs to
+	Logger(ctx context.Context) *slog.Logger
+}
+
+// Span times one step of an execution
+type Span interface {
+	// SetAttributes adds attributes to the span
+	SetAttributes(attrs ...slog.Attr)
+
+	// SetError marks the span as failed with message
+	SetError(message string)
+
+	// End ends the span; later calls do nothing
+	End()
+}
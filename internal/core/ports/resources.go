@@ -0,0 +1,9 @@
+package ports
+
+import "github.com/modelcontextprotocol/go-sdk/mcp"
+
+// ResourceRegistry defines the interface for registering MCP resources
+type ResourceRegistry interface {
+	// RegisterResources registers all available resources with the MCP server
+	RegisterResources(server *mcp.Server)
+}
@@ -0,0 +1,234 @@
+package redact
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// Built-in pattern categories
+const (
+	AWSAccessKey = "aws_access_key"
+	GitHubToken  = "github_token"
+	PrivateKey   = "private_key"
+	JWT          = "jwt"
+	HighEntropy  = "high_entropy"
+	SecretEnv    = "secret_env"
+)
+
+// Builtins holds the built-in patterns by category
+var Builtins = map[string]string{
+	AWSAccessKey: `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`,
+	GitHubToken:  `\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`,
+	PrivateKey:   `(?s)-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----.*?(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|\z)`,
+	JWT:          `\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`,
+}
+
+// Pattern is a named regular expression whose matches are masked
+type Pattern struct {
+	Category string
+	Regexp   *regexp.Regexp
+}
+
+// Entropy flags long tokens that look random. A token is a run of
+// base64 and URL-safe characters at least MinLength long that mixes upper
+// case, lower case and digits, with a Shannon entropy of at least MinBits
+// per character. Zero MinBits disables the check.
+type Entropy struct {
+	MinBits   float64
+	MinLength int
+}
+
+// entropyToken matches candidate tokens for the entropy check
+var entropyToken = regexp.MustCompile(`[A-Za-z0-9+/_-]+={0,2}`)
+
+// secretMinLength is the shortest secret value that is masked. Shorter
+// values would mask ordinary words and numbers.
+const secretMinLength = 6
+
+// Redactor masks secrets in text
+type Redactor struct {
+	patterns []Pattern
+	secrets  []string
+	entropy  Entropy
+}
+
+// New returns a redactor for patterns, the literal secrets and the
+// entropy check. Patterns are applied in order, then secrets, then the
+// entropy check.
+func New(patterns []Pattern, secrets []string, entropy Entropy) *Redactor {
+	r := &Redactor{patterns: patterns, entropy: entropy}
+	for _, secret := range secrets {
+		if len(secret) >= secretMinLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	// Longer secrets first, so a secret containing another is masked whole
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// EnvSecrets returns the values of the variables in environ, as
+// NAME=value entries, whose names match one of the glob patterns
+func EnvSecrets(environ, patterns []string) []string {
+	var secrets []string
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, name); matched {
+				secrets = append(secrets, value)
+				break
+			}
+		}
+	}
+	return secrets
+}
+
+// Placeholder returns the text that replaces a secret of category
+func Placeholder(category string) string {
+	return "[REDACTED:" + category + "]"
+}
+
+// Redact masks the secrets in text and counts them by category into
+// counts, which may be nil when the counts are not needed
+func (r *Redactor) Redact(text string, counts map[string]int) string {
+	if text == "" {
+		return text
+	}
+	record := func(category string, n int) {
+		if counts != nil && n > 0 {
+			counts[category] += n
+		}
+	}
+
+	for _, p := range r.patterns {
+		n := 0
+		text = p.Regexp.ReplaceAllStringFunc(text, func(string) string {
+			n++
+			return Placeholder(p.Category)
+		})
+		record(p.Category, n)
+	}
+
+	for _, secret := range r.secrets {
+		if n := strings.Count(text, secret); n > 0 {
+			text = strings.ReplaceAll(text, secret, Placeholder(SecretEnv))
+			record(SecretEnv, n)
+		}
+	}
+
+	if r.entropy.MinBits > 0 {
+		n := 0
+		text = entropyToken.ReplaceAllStringFunc(text, func(token string) string {
+			if !r.random(token) {
+				return token
+			}
+			n++
+			return Placeholder(HighEntropy)
+		})
+		record(HighEntropy, n)
+	}
+	return text
+}
+
+// RedactResult masks the output of result, and the reports and text
+// artifacts that come with it, and adds the counts to its Redactions
+func (r *Redactor) RedactResult(result *domain.ExecutionResult) {
+	if result == nil {
+		return
+	}
+	counts := map[string]int{}
+	result.Stdout = r.Redact(result.Stdout, counts)
+	result.Stderr = r.Redact(result.Stderr, counts)
+	r.redactReports(result, counts)
+	result.Redactions = merge(result.Redactions, counts)
+}
+
+// redactReports masks the profile and coverage tables of result and its
+// text artifacts. Binary artifacts, such as pprof files, are left as they
+// are, since masking bytes in them would corrupt them.
+func (r *Redactor) redactReports(result *domain.ExecutionResult, counts map[string]int) {
+	if profile := result.Profile; profile != nil {
+		profile.Warning = r.Redact(profile.Warning, counts)
+		for _, table := range profile.Tables {
+			for _, row := range table.Rows {
+				for i := range row {
+					row[i] = r.Redact(row[i], counts)
+				}
+			}
+		}
+	}
+	if coverage := result.Coverage; coverage != nil {
+		for i := range coverage.Files {
+			coverage.Files[i].Path = r.Redact(coverage.Files[i].Path, counts)
+		}
+	}
+	for i, artifact := range result.Artifacts {
+		if textMIMEType(artifact.MIMEType) {
+			result.Artifacts[i].Data = []byte(r.Redact(string(artifact.Data), counts))
+		}
+	}
+}
+
+// textMIMEType reports whether data of the MIME type is text
+func textMIMEType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.TrimSpace(mimeType)
+	return strings.HasPrefix(mimeType, "text/") ||
+		mimeType == "application/json" || strings.HasSuffix(mimeType, "+json") ||
+		mimeType == "application/xml" || strings.HasSuffix(mimeType, "+xml")
+}
+
+// RedactPipeline masks the output of a pipeline and each of its stages
+func (r *Redactor) RedactPipeline(result *domain.PipelineResult) {
+	counts := map[string]int{}
+	result.Stdout = r.Redact(result.Stdout, counts)
+	for _, stage := range result.Stages {
+		if stage != nil {
+			stage.Stderr = r.Redact(stage.Stderr, counts)
+		}
+	}
+	result.Redactions = merge(result.Redactions, counts)
+}
+
+// random reports whether token passes the entropy check
+func (r *Redactor) random(token string) bool {
+	if len(token) < r.entropy.MinLength {
+		return false
+	}
+	var upper, lower, digit bool
+	freq := map[rune]int{}
+	for _, c := range token {
+		upper = upper || unicode.IsUpper(c)
+		lower = lower || unicode.IsLower(c)
+		digit = digit || unicode.IsDigit(c)
+		freq[c]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+
+	bits := 0.0
+	for _, n := range freq {
+		p := float64(n) / float64(len(token))
+		bits -= p * math.Log2(p)
+	}
+	return bits >= r.entropy.MinBits
+}
+
+// merge adds counts to into, allocating it when needed. Empty counts
+// leave into unchanged.
+func merge(into, counts map[string]int) map[string]int {
+	for category, n := range counts {
+		if into == nil {
+			into = map[string]int{}
+		}
+		into[category] += n
+	}
+	return into
+}
@@ -0,0 +1,200 @@
+package risk
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aravi/code_execution_mcp/internal/core/domain"
+)
+
+// Level orders how much harm an execution could do outside its sandbox
+type Level int
+
+// Risk levels, from least to most serious
+const (
+	None Level = iota
+	Low
+	Medium
+	High
+)
+
+// String returns the lowercase name of the level
+func (l Level) String() string {
+	switch l {
+	case None:
+		return "none"
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLevel converts a level name to a Level
+func ParseLevel(name string) (Level, error) {
+	for l := Low; l <= High; l++ {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return None, fmt.Errorf("unknown risk level %q (use low, medium or high)", name)
+}
+
+// Finding is one reason an execution was flagged. Line is 1-based, or 0
+// when the finding is about the request rather than a line of code.
+type Finding struct {
+	Level  Level
+	Reason string
+	Line   int
+}
+
+// Assessment is the classification of one or more executions. Level is
+// the highest level among the findings.
+type Assessment struct {
+	Level    Level
+	Findings []Finding
+}
+
+// Add records a finding and raises the level to match it
+func (a *Assessment) Add(f Finding) {
+	a.Findings = append(a.Findings, f)
+	a.Level = max(a.Level, f.Level)
+}
+
+// Rules tune the classifier. Writes under the working directory, the temp
+// directory and WritableDirs count as inside the workspace.
+type Rules struct {
+	LongTimeoutSeconds int
+	WritableDirs       []string
+}
+
+// pattern flags source lines that match re. When pathArg is set, a line
+// is only flagged if it names a path outside the workspace: the path
+// captured by a group named "path" in re, or else any path literal on the
+// line.
+type pattern struct {
+	re      *regexp.Regexp
+	level   Level
+	reason  string
+	pathArg bool
+}
+
+// pathLiteral matches absolute and home-relative paths in source code
+var pathLiteral = regexp.MustCompile(`(?:^|[\s"'=(,>])((?:/|~/|\$HOME/?|\$\{HOME\}/?)[\w./~-]*)`)
+
+// Patterns for each language family. They are deliberately simple: the
+// classifier decides whether to ask, and the policies and sandbox decide
+// what is possible.
+var (
+	shellPatterns = []pattern{
+		{re: regexp.MustCompile(`\b(sudo|su|doas)\b`), level: High, reason: "runs a command with elevated privileges"},
+		{re: regexp.MustCompile(`>>?\s*["']?(?P<path>(?:/|~/|\$HOME|\$\{HOME\})[\w./~-]*)`), level: High, reason: "writes outside the workspace", pathArg: true},
+		{re: regexp.MustCompile(`\b(rm|mv|cp|tee|touch|mkdir|rmdir|chmod|chown|ln|dd|truncate|install)\b`), level: High, reason: "writes outside the workspace", pathArg: true},
+		{re: regexp.MustCompile(`\b(curl|wget|nc|ncat|netcat|ssh|scp|sftp|rsync|telnet|ftp)\b|/dev/(tcp|udp)/|\bgit\s+(clone|fetch|pull|push)\b`), level: Medium, reason: "uses the network"},
+		{re: regexp.MustCompile(`\b(pip3?|npm|yarn|gem|cargo|apt|apt-get|yum|dnf|brew)\s+install\b|\bgo\s+(get|install)\b`), level: Medium, reason: "installs packages"},
+	}
+	pythonPatterns = []pattern{
+		{re: regexp.MustCompile(`\bopen\(.*['"][wax+]b?['"]|\b(os\.(remove|unlink|rmdir|removedirs|rename|replace|makedirs|mkdir|chmod|chown)|shutil\.\w+|write_text|write_bytes|unlink|rmtree)\b`), level: High, reason: "writes outside the workspace", pathArg: true},
+		{re: regexp.MustCompile(`^\s*(import|from)\s+(socket|ssl|urllib|urllib3|requests|httpx|aiohttp|http|ftplib|smtplib|telnetlib|paramiko|websocket|websockets)\b`), level: Medium, reason: "uses the network"},
+		{re: regexp.MustCompile(`\b(subprocess|os\.system|os\.popen)\b`), level: Low, reason: "starts other programs"},
+	}
+	goPatterns = []pattern{
+		{re: regexp.MustCompile(`\bos\.(WriteFile|Create|OpenFile|Remove|RemoveAll|Rename|Mkdir|MkdirAll|Chmod|Chown|Symlink|Link|Truncate)\b`), level: High, reason: "writes outside the workspace", pathArg: true},
+		{re: regexp.MustCompile(`"net(/[\w/]+)?"`), level: Medium, reason: "uses the network"},
+		{re: regexp.MustCompile(`"os/exec"|"syscall"`), level: Low, reason: "starts other programs or makes system calls"},
+	}
+)
+
+// Classify assesses the risk of a single execution request
+func Classify(req domain.ExecutionRequest, rules Rules) Assessment {
+	var a Assessment
+
+	if rules.LongTimeoutSeconds > 0 && req.Timeout > rules.LongTimeoutSeconds {
+		a.Add(Finding{Level: Medium, Reason: fmt.Sprintf("asks for a %ds timeout, above %ds", req.Timeout, rules.LongTimeoutSeconds)})
+	}
+	if req.WorkingDir != "" && !rules.inWorkspace(req.WorkingDir, "") {
+		a.Add(Finding{Level: Low, Reason: fmt.Sprintf("runs in %s", req.WorkingDir)})
+	}
+
+	var patterns []pattern
+	switch req.Language {
+	case "bash", "zsh", "shell":
+		patterns = shellPatterns
+	case "python", "python3":
+		patterns = pythonPatterns
+	case "go", "golang":
+		patterns = goPatterns
+	}
+
+	seen := map[string]bool{}
+	for _, source := range []string{req.Script, req.Code, req.TestCode} {
+		for i, line := range strings.Split(source, "\n") {
+			for _, p := range patterns {
+				if seen[p.reason] || !p.re.MatchString(line) {
+					continue
+				}
+				reason := p.reason
+				if p.pathArg {
+					target := rules.outsidePath(p.paths(line), req.WorkingDir)
+					if target == "" {
+						continue
+					}
+					reason += " (" + target + ")"
+				}
+				seen[p.reason] = true
+				a.Add(Finding{Level: p.level, Reason: reason, Line: i + 1})
+			}
+		}
+	}
+	return a
+}
+
+// paths returns the paths a matching line writes to
+func (p pattern) paths(line string) []string {
+	re, group := pathLiteral, 1
+	if i := p.re.SubexpIndex("path"); i > 0 {
+		re, group = p.re, i
+	}
+	var paths []string
+	for _, m := range re.FindAllStringSubmatch(line, -1) {
+		paths = append(paths, m[group])
+	}
+	return paths
+}
+
+// outsidePath returns the first of paths that is outside the workspace,
+// or an empty string
+func (r Rules) outsidePath(paths []string, workDir string) string {
+	for _, target := range paths {
+		if !r.inWorkspace(target, workDir) {
+			return target
+		}
+	}
+	return ""
+}
+
+// inWorkspace reports whether target is under workDir, the temp directory,
+// one of the writable directories, or a harmless device
+func (r Rules) inWorkspace(target, workDir string) bool {
+	if !strings.HasPrefix(target, "/") {
+		return false
+	}
+	target = path.Clean(target)
+	dirs := append([]string{"/tmp", "/dev/null", "/dev/stdout", "/dev/stderr", "/dev/fd"}, r.WritableDirs...)
+	if workDir != "" {
+		dirs = append(dirs, workDir)
+	}
+	for _, dir := range dirs {
+		dir = path.Clean(dir)
+		if target == dir || strings.HasPrefix(target, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
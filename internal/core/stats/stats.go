@@ -0,0 +1,189 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes a sample of measurements
+type Summary struct {
+	N      int
+	Min    float64
+	Max    float64
+	Mean   float64
+	Median float64
+	P95    float64
+	Stddev float64
+}
+
+// Summarize computes summary statistics; Stddev is the sample standard
+// deviation. An empty sample yields the zero Summary.
+func Summarize(sample []float64) Summary {
+	if len(sample) == 0 {
+		return Summary{}
+	}
+
+	sorted := append([]float64(nil), sample...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, x := range sorted {
+		sum += x
+	}
+	mean := sum / float64(len(sorted))
+
+	return Summary{
+		N:      len(sorted),
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean,
+		Median: Percentile(sorted, 50),
+		P95:    Percentile(sorted, 95),
+		Stddev: math.Sqrt(variance(sorted, mean)),
+	}
+}
+
+// Percentile returns the p-th percentile of a sorted sample using linear
+// interpolation between closest ranks
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// WelchTTest compares the means of two samples without assuming equal
+// variances. It returns the t statistic, the Welch–Satterthwaite degrees
+// of freedom and the two-sided p-value.
+func WelchTTest(a, b []float64) (t, df, p float64) {
+	if len(a) < 2 || len(b) < 2 {
+		return 0, 0, 1
+	}
+
+	meanA, meanB := mean(a), mean(b)
+	seA := variance(a, meanA) / float64(len(a))
+	seB := variance(b, meanB) / float64(len(b))
+	se := seA + seB
+	if se == 0 {
+		if meanA == meanB {
+			return 0, 0, 1
+		}
+		return math.Inf(sign(meanA - meanB)), math.Inf(1), 0
+	}
+
+	t = (meanA - meanB) / math.Sqrt(se)
+	df = se * se / (seA*seA/float64(len(a)-1) + seB*seB/float64(len(b)-1))
+	p = regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+	return t, df, p
+}
+
+// mean returns the arithmetic mean of a sample
+func mean(sample []float64) float64 {
+	var sum float64
+	for _, x := range sample {
+		sum += x
+	}
+	return sum / float64(len(sample))
+}
+
+// variance returns the sample variance given the sample mean
+func variance(sample []float64, mean float64) float64 {
+	if len(sample) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, x := range sample {
+		sum += (x - mean) * (x - mean)
+	}
+	return sum / float64(len(sample)-1)
+}
+
+// sign returns 1 for non-negative values and -1 otherwise
+func sign(x float64) int {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
+
+// regularizedIncompleteBeta evaluates I_x(a, b) with the continued
+// fraction from Numerical Recipes, using the symmetry relation to keep the
+// fraction in its rapidly converging range
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction for the
+// incomplete beta function with the modified Lentz method
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+
+		// Even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		// Odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b, tol float64) bool {
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return a == b
+	}
+	return math.Abs(a-b) <= tol
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample []float64
+		want   Summary
+	}{
+		{"empty", nil, Summary{}},
+		{"single", []float64{3}, Summary{N: 1, Min: 3, Max: 3, Mean: 3, Median: 3, P95: 3}},
+		{
+			"even",
+			[]float64{9, 2, 5, 4, 4, 7, 4, 5},
+			Summary{N: 8, Min: 2, Max: 9, Mean: 5, Median: 4.5, P95: 8.3, Stddev: math.Sqrt(32.0 / 7)},
+		},
+		{
+			"odd",
+			[]float64{10, 30, 20},
+			Summary{N: 3, Min: 10, Max: 30, Mean: 20, Median: 20, P95: 29, Stddev: 10},
+		},
+		{
+			"constant",
+			[]float64{1.5, 1.5, 1.5, 1.5},
+			Summary{N: 4, Min: 1.5, Max: 1.5, Mean: 1.5, Median: 1.5, P95: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		got := Summarize(tt.sample)
+		if got.N != tt.want.N ||
+			!approx(got.Min, tt.want.Min, 1e-12) ||
+			!approx(got.Max, tt.want.Max, 1e-12) ||
+			!approx(got.Mean, tt.want.Mean, 1e-12) ||
+			!approx(got.Median, tt.want.Median, 1e-12) ||
+			!approx(got.P95, tt.want.P95, 1e-12) ||
+			!approx(got.Stddev, tt.want.Stddev, 1e-12) {
+			t.Errorf("%s: Summarize = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeLeavesSampleUnsorted(t *testing.T) {
+	sample := []float64{3, 1, 2}
+	Summarize(sample)
+	if sample[0] != 3 || sample[1] != 1 || sample[2] != 2 {
+		t.Errorf("Summarize reordered its input: %v", sample)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{25, 1.75},
+		{50, 2.5},
+		{95, 3.85},
+		{100, 4},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); !approx(got, tt.want, 1e-12) {
+			t.Errorf("Percentile(%v, %v) = %v, want %v", sorted, tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil, 50) = %v, want 0", got)
+	}
+}
+
+func TestWelchTTest(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      []float64
+		t, df, p  float64
+		tolerance float64
+	}{
+		{
+			// Two samples of two with equal variance give df = 2, where the
+			// two-sided p-value is 1 - |t|/sqrt(t²+2)
+			name: "closed form df=2",
+			a:    []float64{0, 2},
+			b:    []float64{2, 4},
+			t:    -math.Sqrt2,
+			df:   2,
+			p:    1 - math.Sqrt2/2,
+		},
+		{
+			// Welch's first example: t ≈ -2.46, df ≈ 25.0, p ≈ 0.021
+			name: "unequal variances",
+			a:    []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4},
+			b:    []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4},
+			t:    -2.455356398286,
+			df:   24.988529290231,
+			p:    0.021378001463,
+		},
+		{
+			name: "identical samples",
+			a:    []float64{1, 2, 3},
+			b:    []float64{1, 2, 3},
+			t:    0,
+			df:   4,
+			p:    1,
+		},
+		{
+			name: "too few samples",
+			a:    []float64{1},
+			b:    []float64{1, 2, 3},
+			t:    0,
+			df:   0,
+			p:    1,
+		},
+		{
+			name: "constant and equal",
+			a:    []float64{5, 5},
+			b:    []float64{5, 5, 5},
+			t:    0,
+			df:   0,
+			p:    1,
+		},
+		{
+			name: "constant and different",
+			a:    []float64{5, 5},
+			b:    []float64{7, 7, 7},
+			t:    math.Inf(-1),
+			df:   math.Inf(1),
+			p:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		gotT, gotDF, gotP := WelchTTest(tt.a, tt.b)
+		if !approx(gotT, tt.t, 1e-9) || !approx(gotDF, tt.df, 1e-9) || !approx(gotP, tt.p, 1e-9) {
+			t.Errorf("%s: WelchTTest = (%v, %v, %v), want (%v, %v, %v)",
+				tt.name, gotT, gotDF, gotP, tt.t, tt.df, tt.p)
+		}
+
+		// Swapping the samples flips the sign of t only
+		swapT, swapDF, swapP := WelchTTest(tt.b, tt.a)
+		if !approx(swapT, -gotT, 1e-12) || !approx(swapDF, gotDF, 1e-12) || !approx(swapP, gotP, 1e-12) {
+			t.Errorf("%s: swapped WelchTTest = (%v, %v, %v), want (%v, %v, %v)",
+				tt.name, swapT, swapDF, swapP, -gotT, gotDF, gotP)
+		}
+	}
+}
+
+func TestRegularizedIncompleteBeta(t *testing.T) {
+	tests := []struct {
+		a, b, x float64
+		want    float64
+	}{
+		{2, 3, 0, 0},
+		{2, 3, 1, 1},
+		{3, 1, 0.4, math.Pow(0.4, 3)},
+		{1, 4, 0.3, 1 - math.Pow(0.7, 4)},
+		{7.5, 7.5, 0.5, 0.5},
+		// Student's t with one degree of freedom: p = 1 - 2/π·atan(|t|)
+		{0.5, 0.5, 1.0 / (1 + 3), 1 - 2/math.Pi*math.Atan(math.Sqrt(3))},
+	}
+
+	for _, tt := range tests {
+		if got := regularizedIncompleteBeta(tt.a, tt.b, tt.x); !approx(got, tt.want, 1e-10) {
+			t.Errorf("regularizedIncompleteBeta(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.x, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxCells bounds the size of the LCS table; larger inputs fall back to
+// showing both texts in full as a single hunk
+const maxCells = 4_000_000
+
+// op is a single line of an edit script
+type op struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// Unified returns a unified diff turning a into b, or "" when they are
+// equal. The labels name the two sides in the header.
+func Unified(a, b, labelA, labelB string) string {
+	if a == b {
+		return ""
+	}
+
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+	ops := editScript(aLines, bLines)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", labelA, labelB)
+	for _, h := range hunks(ops) {
+		out.WriteString(h)
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines without their terminators. A missing
+// final newline is marked so that it shows up as a difference.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		if trimmed, ok := strings.CutSuffix(line, "\n"); ok {
+			lines[i] = trimmed
+		} else {
+			lines[i] = line + "\n\\ No newline at end of file"
+		}
+	}
+	return lines
+}
+
+// editScript computes a minimal line edit script with a longest common
+// subsequence table
+func editScript(a, b []string) []op {
+	if len(a)*len(b) > maxCells {
+		ops := make([]op, 0, len(a)+len(b))
+		for _, line := range a {
+			ops = append(ops, op{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, op{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
+
+// hunks groups an edit script into unified diff hunks with context
+func hunks(ops []op) []string {
+	var result []string
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are within two contexts of each other
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*contextLines {
+				break
+			}
+			end = next
+		}
+
+		from := max(start-contextLines, 0)
+		to := min(end+contextLines, len(ops))
+		result = append(result, formatHunk(ops, from, to))
+		start = to
+	}
+	return result
+}
+
+// formatHunk renders ops[from:to] with its @@ header
+func formatHunk(ops []op, from, to int) string {
+	aStart, bStart := 1, 1
+	for _, o := range ops[:from] {
+		if o.kind != '+' {
+			aStart++
+		}
+		if o.kind != '-' {
+			bStart++
+		}
+	}
+
+	var body strings.Builder
+	aCount, bCount := 0, 0
+	for _, o := range ops[from:to] {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+		body.WriteByte(o.kind)
+		body.WriteString(o.text)
+		body.WriteByte('\n')
+	}
+
+	// An empty range starts at the line before it, as in GNU diff
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", aStart, aCount, bStart, bCount, body.String())
+}